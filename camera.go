@@ -0,0 +1,123 @@
+package main
+
+import (
+	"math"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	cameraPanSpeed = 12   // Screen pixels panned per frame by a held key.
+	cameraZoomStep = 1.1  // Zoom multiplied per notch of the mouse wheel.
+	cameraMaxZoom  = 2.0  // Largest zoom, where tiles are twice their size.
+	cameraMinZoom  = 0.25 // Smallest zoom, unless the map would not fill the view.
+)
+
+// Camera is the part of the world shown on screen. All world drawing and
+// cursor conversion goes through it, so the world can be larger than the
+// screen
+type Camera struct {
+	X, Y float64 // world pixel at the top left of the screen
+	Zoom float64 // screen pixels per world pixel
+
+	isPanning  bool   // Is the camera being dragged
+	lastCursor [2]int // cursor position on the last frame
+}
+
+// NewCamera constructs a camera at the top left of the world at full size
+func NewCamera() Camera {
+	return Camera{Zoom: 1}
+}
+
+// GeoM returns the transform from world pixels to screen pixels
+func (c *Camera) GeoM() ebiten.GeoM {
+	geoM := ebiten.GeoM{}
+	geoM.Translate(-c.X, -c.Y)
+	geoM.Scale(c.Zoom, c.Zoom)
+	return geoM
+}
+
+// ToScreen converts a world pixel to a screen pixel
+func (c *Camera) ToScreen(x, y float64) (float64, float64) {
+	return (x - c.X) * c.Zoom, (y - c.Y) * c.Zoom
+}
+
+// ToWorld converts a screen pixel to a world pixel
+func (c *Camera) ToWorld(x, y int) (float64, float64) {
+	return float64(x)/c.Zoom + c.X, float64(y)/c.Zoom + c.Y
+}
+
+// minZoom returns the smallest zoom at which the map still fills the view
+func (c *Camera) minZoom() float64 {
+	return math.Max(cameraMinZoom, math.Max(
+		float64(screenWidth)/float64(sim.StageSizeX*tileSize),
+		float64(screenHeight-lowerHUDHeight)/float64(sim.StageSizeY*tileSize)))
+}
+
+// clamp keeps the view within the map
+func (c *Camera) clamp() {
+	c.Zoom = math.Max(c.minZoom(), math.Min(cameraMaxZoom, c.Zoom))
+	maxX := float64(sim.StageSizeX*tileSize) - float64(screenWidth)/c.Zoom
+	maxY := float64(sim.StageSizeY*tileSize) -
+		float64(screenHeight-lowerHUDHeight)/c.Zoom
+	c.X = math.Max(0, math.Min(maxX, c.X))
+	c.Y = math.Max(0, math.Min(maxY, c.Y))
+}
+
+// ZoomAt multiplies the zoom by a factor, keeping the world pixel under a
+// screen pixel in place
+func (c *Camera) ZoomAt(factor float64, x, y int) {
+	worldX, worldY := c.ToWorld(x, y)
+	c.Zoom *= factor
+	c.clamp()
+	c.X = worldX - float64(x)/c.Zoom
+	c.Y = worldY - float64(y)/c.Zoom
+	c.clamp()
+}
+
+// onCamera pans the camera while the keys are held or the mouse button is
+// dragged, and zooms it towards the cursor with the mouse wheel. The keys
+// and button are passed as parameters
+func (g *Game) onCamera(up, left, down, right ebiten.Key,
+	panButton ebiten.MouseButton) {
+	camera := &g.camera
+	speed := cameraPanSpeed / camera.Zoom
+	if ebiten.IsKeyPressed(up) {
+		camera.Y -= speed
+	}
+	if ebiten.IsKeyPressed(left) {
+		camera.X -= speed
+	}
+	if ebiten.IsKeyPressed(down) {
+		camera.Y += speed
+	}
+	if ebiten.IsKeyPressed(right) {
+		camera.X += speed
+	}
+
+	x, y := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(panButton) {
+		camera.isPanning = true
+	} else if !ebiten.IsMouseButtonPressed(panButton) {
+		camera.isPanning = false
+	}
+	if camera.isPanning {
+		camera.X -= float64(x-camera.lastCursor[0]) / camera.Zoom
+		camera.Y -= float64(y-camera.lastCursor[1]) / camera.Zoom
+	}
+	camera.lastCursor = [2]int{x, y}
+	camera.clamp()
+
+	if _, wheel := ebiten.Wheel(); wheel != 0 && IsInGameArea(x, y) {
+		camera.ZoomAt(math.Pow(cameraZoomStep, wheel), x, y)
+	}
+}
+
+// GetCursorCoordinates returns the tile coordinate that the cursor is within.
+func (g *Game) GetCursorCoordinates() (int, int) {
+	x, y := g.camera.ToWorld(ebiten.CursorPosition())
+	return int(math.Floor(x / float64(tileSize))),
+		int(math.Floor(y / float64(tileSize)))
+}
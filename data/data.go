@@ -0,0 +1,8 @@
+// Package data embeds the object, item and recipe definitions stored in this
+// directory, so the simulation can run without the files beside it.
+package data
+
+import "embed"
+
+//go:embed *.json
+var FS embed.FS
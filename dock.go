@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const dispatchButtonWidth = 96
+
+var (
+	dockValid   color.RGBA = color.RGBA{0x3a, 0x8a, 0x3a, 0x88}
+	dockInvalid color.RGBA = color.RGBA{0x8a, 0x3a, 0x3a, 0x88}
+)
+
+// StartPlacingDock lets the next click place a new dock on the nearest edge
+// of the map
+func (g *Game) StartPlacingDock() {
+	g.ClosePanel()
+	g.isPlacingDock = true
+}
+
+// onPlaceDock buys a dock on the edge nearest the cursor when the mouse
+// button is released, or stops placing if the cancel button is pressed.
+// Returns true if a dock is being placed and used the input
+func (g *Game) onPlaceDock(mouseButton, cancelButton ebiten.MouseButton) bool {
+	if !g.isPlacingDock {
+		return false
+	}
+	if inpututil.IsMouseButtonJustPressed(cancelButton) {
+		g.isPlacingDock = false
+		return true
+	}
+	if inpututil.IsMouseButtonJustReleased(mouseButton) {
+		x, y := ebiten.CursorPosition()
+		if IsInGameArea(x, y) {
+			g.world.BuyDock(sim.PlanDockNear(g.GetCursorCoordinates()))
+		}
+		g.isPlacingDock = false
+	}
+	return true
+}
+
+// DrawDockPlan highlights the bay of the dock that would be placed, green if
+// it can be bought there and red otherwise
+func (g *Game) DrawDockPlan(screen *ebiten.Image) {
+	if !g.isPlacingDock {
+		return
+	}
+	plan := sim.PlanDockNear(g.GetCursorCoordinates())
+	fill := dockInvalid
+	currency, cost := g.world.PlanCost(plan)
+	if g.world.CanBuildDock(plan) && g.world.Currencies[currency] >= cost {
+		fill = dockValid
+	}
+	x, y := g.camera.ToScreen(sim.ToReal(plan.X), sim.ToReal(plan.Y))
+	ebitenutil.DrawRect(screen, x, y,
+		sim.ToReal(plan.Width)*g.camera.Zoom, sim.ToReal(plan.Height)*g.camera.Zoom,
+		fill)
+}
+
+// OpenDispatchPanel opens the dispatch panel of a dock
+func (g *Game) OpenDispatchPanel(dock *sim.Dock) {
+	g.ClosePanel()
+	g.dispatchDock = dock.ID
+}
+
+// OpenTruckDispatchPanel opens the dispatch panel of a truck
+func (g *Game) OpenTruckDispatchPanel(truck *sim.Truck) {
+	g.ClosePanel()
+	g.dispatchTruck = truck.ID
+}
+
+// BuildDispatchPanel returns a panel of the open dock's or truck's dispatch
+// rule: when trucks leave, how long they wait and the mix of dice they wait
+// for. A truck's panel can also return it to its dock's rule
+func (g *Game) BuildDispatchPanel() *Panel {
+	if truck, exists := g.world.Trucks[g.dispatchTruck]; exists {
+		useDock := PanelButton{
+			Label:   "Dock Rule",
+			IsOn:    truck.Dispatch == nil,
+			OnClick: func() { truck.Dispatch = nil },
+		}
+		// changing any setting gives the truck a rule of its own
+		rows := g.dispatchRows(g.world.DispatchRuleOf(truck),
+			func() *sim.DispatchRule { return g.world.OwnDispatchRule(truck) })
+		return &Panel{
+			Title: fmt.Sprintf("%s: when it leaves without being clicked",
+				g.truckLabel(truck)),
+			Rows:        append([][]PanelButton{{useDock}}, rows...),
+			ButtonWidth: dispatchButtonWidth,
+		}
+	}
+
+	dock, exists := g.world.Docks[g.dispatchDock]
+	if !exists {
+		return nil
+	}
+	rule := &dock.Dispatch
+	return &Panel{
+		Title: fmt.Sprintf("%s: when trucks leave without being clicked",
+			g.dockLabel(dock.ID)),
+		Rows: g.dispatchRows(rule,
+			func() *sim.DispatchRule { return rule }),
+		ButtonWidth: dispatchButtonWidth,
+	}
+}
+
+// dispatchRows returns the buttons of a dispatch panel showing a rule.
+// Clicking a button changes the rule returned by edit
+func (g *Game) dispatchRows(
+	rule *sim.DispatchRule,
+	edit func() *sim.DispatchRule,
+) [][]PanelButton {
+	modes := []PanelButton{}
+	for _, mode := range sim.DispatchModes {
+		mode := mode
+		modes = append(modes, PanelButton{
+			Label:   mode.String(),
+			IsOn:    rule.Dispatch == mode,
+			OnClick: func() { edit().Dispatch = mode },
+		})
+	}
+
+	waits := []PanelButton{{Label: "Wait:"}}
+	for _, seconds := range sim.DispatchSeconds {
+		seconds := seconds
+		waits = append(waits, PanelButton{
+			Label:   fmt.Sprintf("%d secs", seconds),
+			IsOn:    rule.Seconds == seconds,
+			OnClick: func() { edit().Seconds = seconds },
+		})
+	}
+
+	mix := []PanelButton{{Label: "Mix:"}}
+	for _, itemType := range g.world.ProducibleTypes() {
+		itemType := itemType
+		mix = append(mix, PanelButton{
+			Label:   fmt.Sprintf("%s x%d", itemType, rule.Mix[itemType]),
+			IsOn:    rule.Mix[itemType] > 0,
+			OnClick: func() { edit().CycleMix(itemType) },
+		})
+	}
+	return [][]PanelButton{modes, waits, mix}
+}
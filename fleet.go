@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const fleetButtonWidth = 120
+
+// onFleet opens or closes the fleet panel if the right key has been
+// pressed. The key is passed as a parameter
+func (g *Game) onFleet(key ebiten.Key) {
+	if !inpututil.IsKeyJustPressed(key) || g.isDragging {
+		return
+	}
+	isOpen := g.isFleetOpen
+	g.ClosePanel()
+	g.isFleetOpen = !isOpen
+}
+
+// truckLabel returns the name a truck is listed by, numbered oldest first
+func (g *Game) truckLabel(truck *sim.Truck) string {
+	for index, other := range g.world.SortedTrucks() {
+		if other == truck {
+			return fmt.Sprintf("#%d %s", index+1, truck.Truck)
+		}
+	}
+	return truck.Truck.String()
+}
+
+// dockLabel returns the name a dock is listed by, numbered oldest first
+func (g *Game) dockLabel(id uint64) string {
+	for index, dock := range g.world.SortedDocks() {
+		if dock.ID == id {
+			return fmt.Sprintf("Dock %d", index+1)
+		}
+	}
+	return "No Dock"
+}
+
+// BuildFleetPanel returns a panel to build docks and open their dispatch
+// panels, buy trucks for a chosen dock, move each truck between docks and
+// open each truck's route panel
+func (g *Game) BuildFleetPanel() *Panel {
+	world := g.world
+	docks := world.SortedDocks()
+	if len(docks) == 0 {
+		return nil
+	}
+	if _, exists := world.Docks[g.fleetDock]; !exists {
+		g.fleetDock = docks[0].ID
+	}
+
+	buyFor := []PanelButton{{Label: "Buy for:"}}
+	for _, dock := range docks {
+		dock := dock
+		buyFor = append(buyFor, PanelButton{
+			Label:   g.dockLabel(dock.ID),
+			IsOn:    g.fleetDock == dock.ID,
+			OnClick: func() { g.fleetDock = dock.ID },
+		})
+	}
+
+	buys := []PanelButton{}
+	for _, truckType := range sim.TruckTypes() {
+		truckType := truckType
+		_, cost := world.TruckCost(truckType)
+		buys = append(buys, PanelButton{
+			Label: fmt.Sprintf("%s %d", truckType, cost),
+			OnClick: func() {
+				world.BuyTruck(truckType, world.Docks[g.fleetDock])
+			},
+		})
+	}
+
+	_, dockCost := world.DockCost()
+	dockRow := []PanelButton{{
+		Label:   fmt.Sprintf("Build Dock %d", dockCost),
+		OnClick: g.StartPlacingDock,
+	}}
+	for _, dock := range docks {
+		dock := dock
+		dockRow = append(dockRow, PanelButton{
+			Label:   fmt.Sprintf("%s: %s", g.dockLabel(dock.ID), dock.Dispatch.Dispatch),
+			IsOn:    dock.Dispatch.Dispatch != sim.ManualDispatch,
+			OnClick: func() { g.OpenDispatchPanel(dock) },
+		})
+	}
+
+	rows := [][]PanelButton{dockRow, buyFor, buys}
+	for _, truck := range world.SortedTrucks() {
+		truck := truck
+		row := []PanelButton{{Label: g.truckLabel(truck)}}
+		for _, dock := range docks {
+			dock := dock
+			row = append(row, PanelButton{
+				Label:   g.dockLabel(dock.ID),
+				IsOn:    truck.DockID == dock.ID,
+				OnClick: func() { world.AssignTruck(truck, dock) },
+			})
+		}
+		row = append(row, PanelButton{
+			Label:   "Route",
+			IsOn:    len(truck.Route.Stops) > 1,
+			OnClick: func() { g.OpenRoutePanel(truck) },
+		})
+		rows = append(rows, row)
+	}
+
+	return &Panel{
+		Title:       "Fleet: build docks and set when trucks leave them, buy trucks and move waiting trucks",
+		Rows:        rows,
+		ButtonWidth: fleetButtonWidth,
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"image/color"
+	"time"
 
+	"github.com/Rolls71/dice-factory/sim"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
@@ -14,75 +16,202 @@ const (
 	hotbarSpacing  = 5
 )
 
+const awaySummarySeconds = 15 // Seconds the away summary is shown
+
 var opaqueGrey color.RGBA = color.RGBA{0x55, 0x55, 0x55, 0x99}
 
-// UnlockObject attempts to add an object to a hotbar if it has a specific count
-func (g *Game) UnlockObject(objectType ObjectType) {
-	switch objectType {
-	case Builder:
-		if g.ObjectCount[objectType] == 4 {
-			g.SpawnUIObject(Upgrader)
-		}
-	}
+// UIObject is an unlocked ObjectType displayed in the hotbar
+type UIObject struct {
+	Object sim.ObjectType
+
+	uiPosition int  // stores position of ui objects
+	isDragged  bool // default false
 }
 
 // InitHUD adds UIObjects to hotbar.
 // Run InitHUD after objectImages are initialised
 func (g *Game) InitHUD() {
-	g.SpawnUIObject(ConveyorBelt)
-	g.SpawnUIObject(Builder)
+	g.UpdateHUD()
+}
+
+// UpdateHUD adds any newly unlocked objects to the hotbar
+func (g *Game) UpdateHUD() {
+	for _, objectType := range g.world.Unlocked[len(g.UIObjects):] {
+		g.SpawnUIObject(objectType)
+	}
+	if g.awayTimer > 0 {
+		g.awayTimer--
+	}
+}
+
+// ShowAwaySummary displays the progress made while the game was closed.
+// Nothing is shown if the summary is nil or no progress was made
+func (g *Game) ShowAwaySummary(summary *sim.OfflineSummary) {
+	if summary == nil || summary.Elapsed < time.Second {
+		return
+	}
+	g.awaySummary = summary
+	g.awayTimer = awaySummarySeconds * sim.TickRate
 }
 
 // SpawnUIObject constructs a new object of ObjectType in the UI overlay
 func (g *Game) SpawnUIObject(
-	object ObjectType,
+	object sim.ObjectType,
 ) {
-	g.UIObjects = append(g.UIObjects, &Object{Object: object})
+	g.UIObjects = append(g.UIObjects, &UIObject{Object: object})
 }
 
 // DrawHUD calls HUD-related draw functions
 func (g *Game) DrawHUD(screen *ebiten.Image) {
 	g.DrawHotbar(screen)
 
+	world := g.world
 	printString := ""
 
-	if g.Currencies[PlainBuck] > 0 {
-		printString += fmt.Sprintf("PlainBucks: %d\n", g.Currencies[PlainBuck])
-	}
-	if g.Currencies[GoldBuck] > 0 {
-		printString += fmt.Sprintf("GoldBucks: %d\n", g.Currencies[GoldBuck])
-	}
-	if g.Currencies[PlainBuck] > 0 || g.Currencies[GoldBuck] > 0 {
+	if g.awayTimer > 0 {
+		printString += fmt.Sprintf("While you were away (%s):\n",
+			g.awaySummary.Elapsed.Round(time.Second))
+		if g.awaySummary.Skipped >= time.Second {
+			printString += fmt.Sprintf(
+				"(%s more was skipped, too long to catch up on)\n",
+				g.awaySummary.Skipped.Round(time.Second))
+		}
+		printString += fmt.Sprintf("Dice Produced: %d\n", g.awaySummary.Produced)
+		printString += fmt.Sprintf("PlainBucks Earned: %d\n",
+			g.awaySummary.Earned[sim.PlainBuck])
+		printString += fmt.Sprintf("GoldBucks Earned: %d\n",
+			g.awaySummary.Earned[sim.GoldBuck])
 		printString += "\n"
 	}
-	_, val := g.Cost(ConveyorBelt)
-	printString += fmt.Sprintf("Conveyor Belt: %d PlainBucks\n", val)
-	_, val = g.Cost(Builder)
-	printString += fmt.Sprintf("Builder: %d PlainBucks\n", val)
 
-	if len(g.UIObjects) > 2 {
-		_, val = g.Cost(Upgrader)
-		printString += fmt.Sprintf("Upgrader: %d PlainBucks\n", val)
+	if g.isPlacingDock {
+		currency, cost := world.PlanCost(sim.PlanDockNear(g.GetCursorCoordinates()))
+		printString += fmt.Sprintf(
+			"Click near an edge to build a dock for %d %ss (right click to cancel)\n\n",
+			cost, currency)
+	}
+	if g.isLandShown {
+		currency, cost := world.LandCost()
+		printString += fmt.Sprintf(
+			"Click a highlighted chunk to buy it for %d %ss (right click to close)\n\n",
+			cost, currency)
 	}
 
-	if g.Warehouse.Count > 0 {
+	if world.Currencies[sim.PlainBuck] > 0 {
+		printString += fmt.Sprintf("PlainBucks: %d\n", world.Currencies[sim.PlainBuck])
+	}
+	if world.Currencies[sim.GoldBuck] > 0 {
+		printString += fmt.Sprintf("GoldBucks: %d\n", world.Currencies[sim.GoldBuck])
+	}
+	if world.Currencies[sim.PlainBuck] > 0 || world.Currencies[sim.GoldBuck] > 0 {
 		printString += "\n"
-		printString += fmt.Sprintf("Warehouse Dice: %d/%d\n", g.Warehouse.Count, g.Warehouse.Capacity)
-		printString += fmt.Sprintf("Dice Sell Rate: 1 Dice/%d secs\n", sellRate)
+	}
+	for _, objectType := range sim.ObjectTypes() {
+		if !world.IsUnlocked(objectType) {
+			continue
+		}
+		currency, cost := world.Cost(objectType)
+		printString += fmt.Sprintf("%s: %d %ss\n", objectType, cost, currency)
 	}
 
-	val = 0
-	for id := range g.Trucks {
-		if id > val {
-			val = id
+	x, y := g.GetCursorCoordinates()
+	if sim.IsInStage(x, y) {
+		tile := world.TileAt(x, y)
+		currency, foundation := world.FoundationCost(x, y)
+		switch {
+		case tile.IsBlocked():
+			printString += fmt.Sprintf("%s: nothing can be built here\n", tile)
+		case foundation > 0:
+			printString += fmt.Sprintf(
+				"%s: building here needs a foundation for %d %ss\n",
+				tile, foundation, currency)
+		default:
+			boosts, speed := tile.Boosts()
+			if boosts != sim.NoBehavior {
+				printString += fmt.Sprintf(
+					"%s: %s machines work %d times as fast here\n",
+					tile, boosts, speed)
+			}
 		}
 	}
-	printString += "\n"
-	printString += fmt.Sprintf("Dice Stored in Truck: %d/%d\n", g.Trucks[val].Storage.Count, g.Trucks[val].Storage.Capacity)
-	for itemType := range g.Trucks[val].Storage.Dice {
-		printString += fmt.Sprintf("Type Stored in Truck: %s\n", itemType.String())
+	isObject, object := world.GetObjectAt(x, y)
+	if isObject && object.Object.Behavior() == sim.SplitterBehavior {
+		printString += fmt.Sprintf("Splitter Ratio: %d:%d (T to change)\n",
+			object.SplitRatio[0], object.SplitRatio[1])
+	}
+	if isObject && object.Object.Behavior() == sim.MergerBehavior {
+		printString += fmt.Sprintf("Merger Mode: %s (T to change)\n",
+			object.MergeMode.String())
+	}
+	if isObject && object.Object.Behavior() == sim.SorterBehavior {
+		printString += "Sorter (click to configure)\n"
+	}
+	if isObject && object.Object.Behavior() == sim.AssemblerBehavior {
+		printString += fmt.Sprintf("Assembler: %s (click to configure)\n",
+			object.Recipe)
+		recipe, isRecipe := sim.RecipeNamed(object.Recipe)
+		if isRecipe {
+			for _, input := range recipe.Inputs {
+				printString += fmt.Sprintf("  %s: %d/%d\n", input.Item,
+					object.AssembleBuffer[input.Item], input.Count)
+			}
+		}
+	}
+	if isObject && object.Object.Behavior() == sim.RerollerBehavior {
+		printString += fmt.Sprintf("Reroller: rerolls below %d (click to configure)\n",
+			object.RerollBelow)
+	}
+
+	printString += "\nWarehouses (H to manage):\n"
+	for index, warehouse := range world.SortedWarehouses() {
+		printString += fmt.Sprintf("#%d %s: %d/%d dice, sells 1 die/%d secs\n",
+			index+1, warehouse.Warehouse, warehouse.Storage.Count,
+			warehouse.Storage.Capacity, warehouse.Warehouse.SellSeconds())
+	}
+
+	printString += "\nMarket Demand (M for prices):\n"
+	for _, itemType := range world.ProducibleTypes() {
+		printString += fmt.Sprintf("%s: %.0f%%\n", itemType,
+			world.Market.AveragePrice(itemType)/sim.BaseAveragePrice(itemType)*100)
+	}
+
+	if len(world.Orders) > 0 {
+		printString += "\nOrders:\n"
+	}
+	for _, order := range world.SortedOrders() {
+		printString += fmt.Sprintf("%s: %d %ss, %s left\n", order,
+			order.Reward, order.Item.Currency(), order.TimeLeft().Round(time.Second))
+	}
+
+	printString += "\nTrucks (F for fleet and docks, T on truck for dispatch, O for routes):\n"
+	isFullTruck := false
+	for _, truck := range world.SortedTrucks() {
+		status := world.TruckStatus(truck)
+		printString += fmt.Sprintf("%s (%s): %s, %d/%d dice, %s",
+			g.truckLabel(truck), g.dockLabel(truck.DockID), status,
+			truck.Storage.Count, truck.Storage.Capacity,
+			g.world.DispatchRuleOf(truck).Dispatch)
+		if truck.Dispatch == nil {
+			printString += " (dock)"
+		}
+		if upkeep := truck.Truck.Upkeep(); upkeep > 0 {
+			printString += fmt.Sprintf(", upkeep %d/min", upkeep)
+		}
+		printString += "\n"
+		printString += fmt.Sprintf("  Route: %s\n", g.routeLabel(truck))
+		if truck.Unloaded > 0 || truck.Retained > 0 {
+			printString += fmt.Sprintf("  Last Delivery: %d dice unloaded", truck.Unloaded)
+			if truck.Retained > 0 {
+				printString += fmt.Sprintf(", %d kept on truck (warehouses full)",
+					truck.Retained)
+			}
+			printString += "\n"
+		}
+		if status == sim.TruckLoading && truck.Storage.Count >= truck.Storage.Capacity {
+			isFullTruck = true
+		}
 	}
-	if g.Trucks[val].Storage.Count >= g.Trucks[val].Storage.Capacity {
+	if isFullTruck {
 		printString += "Click truck to deliver dice to warehouse"
 	}
 
@@ -1,18 +1,11 @@
 package main
 
 import (
+	"github.com/Rolls71/dice-factory/sim"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-// GetCursorCoordinates returns the tile coordinate that the cursor is within.
-func GetCursorCoordinates() (int, int) {
-	x, y := ebiten.CursorPosition()
-	x /= tileSize
-	y /= tileSize
-	return x, y
-}
-
 // IsInGameArea returns true if the coordinate is within the games boundaries
 func IsInGameArea(x, y int) bool {
 	return (x > 0 &&
@@ -24,23 +17,78 @@ func IsInGameArea(x, y int) bool {
 // UpdateInput runs all major input functions.
 // Keys can be rebound here
 func (g *Game) UpdateInput() {
+	g.onCamera(ebiten.KeyW, ebiten.KeyA, ebiten.KeyS, ebiten.KeyD,
+		ebiten.MouseButtonMiddle)
+	g.onWarehouse(ebiten.KeyH)
+	g.onFleet(ebiten.KeyF)
+	g.onLand(ebiten.KeyL)
+	if g.UpdatePanel() {
+		return
+	}
+	if g.onPlaceDock(ebiten.MouseButtonLeft, ebiten.MouseButtonRight) {
+		return
+	}
+	if g.onBuyLand(ebiten.MouseButtonLeft, ebiten.MouseButtonRight) {
+		return
+	}
+	g.onDebugInput()
 	g.onClick(ebiten.MouseButtonLeft)
 	g.onDragStart(ebiten.MouseButtonLeft)
 	g.onDragEnd(ebiten.MouseButtonLeft)
 	g.onRotate(ebiten.KeyR)
+	g.onConfigure(ebiten.KeyT)
+	g.onMarket(ebiten.KeyM)
+	g.onRoutes(ebiten.KeyO)
+}
+
+// onDebugInput handles temporary inputs before system is put in place
+func (g *Game) onDebugInput() {
+	x, y := g.GetCursorCoordinates()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		isObject, object := g.world.GetObjectAt(x, y)
+		if isObject {
+			g.world.SpawnItem(sim.PlainD6, object)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.Key1) {
+		isObject, object := g.world.GetObjectAt(x, y)
+		if isObject {
+			g.deleteObject(object)
+		} else {
+			g.world.Buy(sim.ConveyorBelt, x, y, sim.South)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.Key2) {
+		isObject, object := g.world.GetObjectAt(x, y)
+		if isObject {
+			g.deleteObject(object)
+		} else {
+			g.world.Buy(sim.Builder, x, y, sim.South)
+		}
+	}
+}
+
+// deleteObject removes an object from the world, and stops dragging it
+func (g *Game) deleteObject(object *sim.Object) {
+	if !g.world.DeleteObject(object) {
+		return
+	}
+	if object == g.draggedObject {
+		g.draggedObject = nil
+		g.isDragging = false
+	}
+	if object == g.panelObject {
+		g.ClosePanel()
+	}
 }
 
 func (g *Game) onClick(mouseButton ebiten.MouseButton) {
 	if inpututil.IsMouseButtonJustReleased(mouseButton) {
-		x, y := GetCursorCoordinates()
-		if IsInGameArea(x, y) {
-			for _, truck := range g.Trucks {
-				if truck.Collectors[0].IsCollecting &&
-					x >= ToTile(truck.X) &&
-					x < ToTile(truck.X)+truck.Width &&
-					y >= ToTile(truck.Y) &&
-					y < ToTile(truck.Y)+truck.Height {
-					truck.Send()
+		if IsInGameArea(ebiten.CursorPosition()) {
+			x, y := g.GetCursorCoordinates()
+			for _, truck := range g.world.Trucks {
+				if g.world.IsLoading(truck) && truck.IsAt(x, y) {
+					g.world.SendTruck(truck)
 				}
 			}
 		}
@@ -48,7 +96,7 @@ func (g *Game) onClick(mouseButton ebiten.MouseButton) {
 }
 
 // onDragStart tests if an Object has been selected.
-// The Game's isDragging flag and the Object's trackMouse flag is set to true.
+// The Game's isDragging flag is set to true and the Object is tracked.
 func (g *Game) onDragStart(mouseButton ebiten.MouseButton) {
 	if inpututil.IsMouseButtonJustPressed(mouseButton) &&
 		!g.isDragging {
@@ -63,10 +111,10 @@ func (g *Game) onDragStart(mouseButton ebiten.MouseButton) {
 				}
 			}
 		} else {
-			xTile, yTile := GetCursorCoordinates()
-			isObject, object := g.GetObjectAt(xTile, yTile)
-			if isObject && object.Object != Collector {
-				object.isDragged = true
+			xTile, yTile := g.GetCursorCoordinates()
+			isObject, object := g.world.GetObjectAt(xTile, yTile)
+			if isObject && object.Object.Behavior() != sim.CollectorBehavior {
+				g.draggedObject = object
 				g.isDragging = true
 			}
 		}
@@ -74,34 +122,33 @@ func (g *Game) onDragStart(mouseButton ebiten.MouseButton) {
 }
 
 // onDragEnd tests if a dragged object has been released.
-// The Game's isDragging flag and the Object's trackMouse flag is set to false.
+// The Game's isDragging flag is set to false and the Object is dropped.
 func (g *Game) onDragEnd(mouseButton ebiten.MouseButton) {
 	if inpututil.IsMouseButtonJustReleased(mouseButton) &&
 		g.isDragging {
-		pixelX, pixelY := ebiten.CursorPosition()
-		tileX := pixelX / tileSize
-		tileY := pixelY / tileSize
-		isObject, _ := g.GetObjectAt(tileX, tileY)
+		tileX, tileY := g.GetCursorCoordinates()
+		canPlace := IsInGameArea(ebiten.CursorPosition()) &&
+			g.world.CanPlace(tileX, tileY)
 		for _, object := range g.UIObjects {
 			if object.isDragged {
 				object.isDragged = false
 				g.isDragging = false
-				if !isObject && IsInGameArea(pixelX, pixelY) {
-					g.Buy(object.Object, pixelX/tileSize, pixelY/tileSize, South)
+				if canPlace {
+					g.world.Buy(object.Object, tileX, tileY, sim.South)
 				}
 				return
 			}
 		}
-		for _, object := range g.Objects {
-			if object.isDragged {
-				object.isDragged = false
-				g.isDragging = false
-				if !isObject && IsInGameArea(pixelX, pixelY) {
-					object.X = pixelX / tileSize
-					object.Y = pixelY / tileSize
-				}
-				return
+		if g.draggedObject != nil {
+			// an object released where it was picked up has been clicked
+			if g.draggedObject.X == tileX && g.draggedObject.Y == tileY {
+				g.OpenPanel(g.draggedObject)
 			}
+			if canPlace && g.world.BuildFoundation(tileX, tileY) {
+				g.world.MoveObject(g.draggedObject, tileX, tileY)
+			}
+			g.draggedObject = nil
+			g.isDragging = false
 		}
 	}
 }
@@ -111,18 +158,40 @@ func (g *Game) onDragEnd(mouseButton ebiten.MouseButton) {
 func (g *Game) onRotate(key ebiten.Key) {
 	if inpututil.IsKeyJustPressed(key) {
 		if g.isDragging {
-			for index, object := range g.Objects {
-				if object.isDragged {
-					g.Objects[index].Rotate()
-					break
-				}
+			if g.draggedObject != nil {
+				g.draggedObject.Rotate()
 			}
 		} else {
-			x, y := GetCursorCoordinates()
-			isObject, object := g.GetObjectAt(x, y)
+			x, y := g.GetCursorCoordinates()
+			isObject, object := g.world.GetObjectAt(x, y)
 			if isObject {
 				object.Rotate()
 			}
 		}
 	}
 }
+
+// onConfigure will change the settings of an object under the cursor, or
+// open the dispatch panel of a truck under the cursor, if the right key has
+// been pressed. The key is passed as a parameter
+func (g *Game) onConfigure(key ebiten.Key) {
+	if inpututil.IsKeyJustPressed(key) && !g.isDragging {
+		x, y := g.GetCursorCoordinates()
+		for _, truck := range g.world.Trucks {
+			if truck.IsAt(x, y) {
+				g.OpenTruckDispatchPanel(truck)
+				return
+			}
+		}
+		isObject, object := g.world.GetObjectAt(x, y)
+		if !isObject {
+			return
+		}
+		switch object.Object.Behavior() {
+		case sim.SplitterBehavior:
+			object.CycleSplitRatio()
+		case sim.MergerBehavior:
+			object.CycleMergeMode()
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+var (
+	landUnowned    color.RGBA = color.RGBA{0x00, 0x00, 0x00, 0x66}
+	landAffordable color.RGBA = color.RGBA{0x3a, 0x8a, 0x3a, 0x66}
+	landExpensive  color.RGBA = color.RGBA{0x8a, 0x3a, 0x3a, 0x66}
+)
+
+// onLand shows or hides the land overlay if the right key has been pressed
+// while nothing is being dragged. The key is passed as a parameter
+func (g *Game) onLand(key ebiten.Key) {
+	if inpututil.IsKeyJustPressed(key) && !g.isDragging {
+		g.isLandShown = !g.isLandShown
+	}
+}
+
+// onBuyLand buys the chunk of land under the cursor when the mouse button is
+// released, or hides the overlay if the cancel button is pressed.
+// Returns true if the land overlay is shown and used the input
+func (g *Game) onBuyLand(mouseButton, cancelButton ebiten.MouseButton) bool {
+	if !g.isLandShown {
+		return false
+	}
+	if inpututil.IsMouseButtonJustPressed(cancelButton) {
+		g.isLandShown = false
+		return true
+	}
+	if inpututil.IsMouseButtonJustReleased(mouseButton) &&
+		IsInGameArea(ebiten.CursorPosition()) {
+		g.world.BuyLand(sim.ChunkAt(g.GetCursorCoordinates()))
+	}
+	return true
+}
+
+// DrawLand shades the land the factory does not own. While the overlay is
+// shown, chunks that can be bought are highlighted with their price, green
+// if they can be afforded and red otherwise
+func (g *Game) DrawLand(screen *ebiten.Image) {
+	size := float64(sim.ChunkSize*tileSize) * g.camera.Zoom
+	currency, cost := g.world.LandCost()
+	for chunkY := 0; chunkY < sim.LandChunksY; chunkY++ {
+		for chunkX := 0; chunkX < sim.LandChunksX; chunkX++ {
+			if g.world.Land[chunkY][chunkX] {
+				continue
+			}
+			x, y := g.camera.ToScreen(
+				sim.ToReal(chunkX*sim.ChunkSize), sim.ToReal(chunkY*sim.ChunkSize))
+			ebitenutil.DrawRect(screen, x, y, size, size, landUnowned)
+			if !g.isLandShown || !g.world.CanBuyLand(chunkX, chunkY) {
+				continue
+			}
+
+			fill := landExpensive
+			if g.world.Currencies[currency] >= cost {
+				fill = landAffordable
+			}
+			ebitenutil.DrawRect(screen, x+1, y+1, size-2, size-2, fill)
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d %ss", cost, currency),
+				int(x+size/2)-32, int(y+size/2)-8)
+		}
+	}
+}
@@ -1,14 +1,14 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"flag"
 	_ "image/png"
 	"log"
 	"os"
+	"time"
 
+	"github.com/Rolls71/dice-factory/sim"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 const (
@@ -16,218 +16,163 @@ const (
 	screenHeight int = 768
 )
 
-const tileSize int = 64
+const tileSize int = sim.TileSize
 
-const (
-	frameRate  int     = 60
-	frameDelta float64 = 1.0 / float64(frameRate)
-)
-
-const (
-	stageSizeX int = screenWidth/tileSize + 1
-	stageSizeY int = screenHeight / tileSize
-)
-
-const saveFilename string = "save.json"
-
-const maxUint64 = ^uint64(0)
+const dataDirectory = "data" // Object, item and recipe definitions
 
-// ToReal converts a tile coordinate to a real coordinate
-func ToReal(i int) float64 {
-	return float64(i * tileSize)
-}
-
-// ToTile converts a real coordinate to a tile coordinate
-func ToTile(f float64) int {
-	return int(f) / tileSize
-}
-
-// Game stores all data relevant to the running game
-// TODO: Reorganise tileSet to store only images
+// Game draws a World and passes player input to it
 type Game struct {
-	tileImages   map[TileType]*ebiten.Image   // Stores different types of Tiles.
-	objectImages map[ObjectType]*ebiten.Image // Stores different object images.
-	itemImages   map[ItemType]*ebiten.Image   // Stores different item images.
-	truckImages  map[TruckType]*ebiten.Image
-
-	TileStage   [stageSizeY][stageSizeX]int // Stores Tile instances to be drawn.
-	Objects     map[uint64]*Object          // Stores Object instances to be drawn.
-	UIObjects   []*Object                   // Stores Objects in the UI Overlay
-	ObjectCount map[ObjectType]uint64       // Tracks the number of Objects
-	Items       map[uint64]*Item            // Stores Item instances to be drawn.
-	Currencies  map[CurrencyType]uint64     // Stores different currencies
-	Storages    map[uint64]*Storage         // Stores a list of trucks and warehouses
-	Trucks      map[uint64]*Truck
-	Warehouse   *Storage // Stores the main storage stuct
-	ID          uint64   // Stores id of last item/object made.
-
-	ticks      uint64 // Stores tick count
-	isDragging bool   // Is an Object being dragged
-
-}
-
-// NextID increments the stored id and returns it
-func (g *Game) NextID() uint64 {
-	g.ID++
-	return g.ID
+	tileImages   map[sim.TileType]*ebiten.Image   // Stores different types of Tiles.
+	objectImages map[sim.ObjectType]*ebiten.Image // Stores different object images.
+	itemImages   map[sim.ItemType]*ebiten.Image   // Stores different item images.
+	truckImages  map[sim.TruckType]*ebiten.Image
+
+	world     *sim.World  // Stores the simulated factory
+	slot      string      // Name of the save slot the world is stored in
+	UIObjects []*UIObject // Stores Objects in the UI Overlay
+	menu      SaveMenu    // Lists save slots to switch between
+	camera    Camera      // Part of the world shown on screen
+
+	autosaver     *Autosaver    // Writes saves in the background
+	autosaveTimer int           // Frames since the last autosave
+	offlineCap    time.Duration // Maximum time caught up on load
+
+	draggedObject *sim.Object // Object being dragged around the world
+	isDragging    bool        // Is an Object being dragged
+	panelObject   *sim.Object // Object whose settings panel is open
+	isMarketOpen  bool        // Is the market overlay shown
+
+	isWarehouseOpen bool         // Is the warehouse panel open
+	dispatchDock    uint64       // ID of the dock whose dispatch panel is open
+	dispatchTruck   uint64       // ID of the truck whose dispatch panel is open
+	isPlacingDock   bool         // Does the next click place a dock
+	isFleetOpen     bool         // Is the fleet panel open
+	fleetDock       uint64       // ID of the dock the fleet panel buys trucks for
+	routeTruck      uint64       // ID of the truck whose route panel is open
+	isRoutesShown   bool         // Is every truck's route drawn
+	isLandShown     bool         // Is the land overlay shown
+	warehouseItem   sim.ItemType // Type of die the warehouse panel sells
+
+	awaySummary *sim.OfflineSummary // Progress made while the game was closed
+	awayTimer   int                 // Frames left to show the awaySummary
 }
 
 // InitImages will initialise all images
 func (g *Game) InitImages() {
-	g.NewTile(BasicGrass, "basic_grass.png")
-	g.NewTile(LongGrass, "long_grass.png")
+	g.tileImages = map[sim.TileType]*ebiten.Image{}
+	g.objectImages = map[sim.ObjectType]*ebiten.Image{}
+	g.itemImages = map[sim.ItemType]*ebiten.Image{}
+	g.truckImages = map[sim.TruckType]*ebiten.Image{}
 
-	g.NewObject(PlainObject, "plain_object.png")
-	g.NewObject(ConveyorBelt, "conveyor_belt.png")
-	g.NewObject(Builder, "builder.png")
-	g.NewObject(Collector, "plain_object.png")
-	g.NewObject(Upgrader, "builder.png")
+	for _, tileType := range sim.TileTypes() {
+		g.NewTile(tileType, tileType.Sprite())
+	}
 
-	g.NewItem(PlainD6, "d6.png")
-	g.NewItem(GoldD6, "gold_d6.png")
+	for _, objectType := range sim.ObjectTypes() {
+		g.NewObject(objectType, objectType.Sprite())
+	}
+	for _, itemType := range sim.ItemTypes() {
+		g.NewItem(itemType, itemType.Sprite())
+	}
 
-	g.NewTruck(BasicTruck, "truck.png")
+	for _, truckType := range sim.TruckTypes() {
+		g.NewTruck(truckType, truckType.Sprite())
+	}
 }
 
-// NewGame constructs and returns a Game struct.
-func NewGame() *Game {
-
+// NewGame constructs a Game around the World stored in the given slot.
+func NewGame(slot string, world *sim.World, offlineCap time.Duration) *Game {
 	game := Game{
-		tileImages:   map[TileType]*ebiten.Image{},
-		objectImages: map[ObjectType]*ebiten.Image{},
-		itemImages:   map[ItemType]*ebiten.Image{},
-		truckImages:  map[TruckType]*ebiten.Image{},
-
-		TileStage:   [stageSizeY][stageSizeX]int{},
-		Objects:     map[uint64]*Object{},
-		UIObjects:   []*Object{},
-		ObjectCount: map[ObjectType]uint64{},
-		Items:       map[uint64]*Item{},
-		Currencies:  map[CurrencyType]uint64{},
-		Storages:    map[uint64]*Storage{},
-		Trucks:      map[uint64]*Truck{},
-	}
-
-	game.Warehouse = game.NewStorage(Warehouse, warehouseCapacity, 0)
-
-	// set up tile stage
-	game.TileStage = [stageSizeY][stageSizeX]int{
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0},
-		{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0},
-		{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		autosaver:  NewAutosaver(),
+		offlineCap: offlineCap,
 	}
 
 	game.InitImages()
-	game.InitHUD()
-
-	builder := game.SpawnObject(Builder, 6, 4, South)
-	game.SpawnObject(ConveyorBelt, 6, 5, West)
-
-	collector1 := game.SpawnObject(Collector, 5, 5, South)
-	collector2 := game.SpawnObject(Collector, 5, 6, South)
+	game.SetWorld(slot, world)
 
-	game.SpawnTruck(BasicTruck, []*Object{collector1, collector2},
-		-5, 5, 2, 5, 4, 2)
+	return &game
+}
 
-	game.SpawnItem(PlainD6, builder)
+// SetWorld replaces the game's world with the one stored in given slot.
+// Progress made since the world was saved is caught up.
+func (g *Game) SetWorld(slot string, world *sim.World) {
+	g.world = world
+	g.slot = slot
+	g.UIObjects = []*UIObject{}
+	g.draggedObject = nil
+	g.isDragging = false
+	g.ClosePanel()
+	g.camera = NewCamera()
+	g.autosaveTimer = 0
+
+	world.Ticks = 60 * 7
+
+	// new worlds have nothing to catch up
+	var summary *sim.OfflineSummary
+	if !world.SavedAt.IsZero() {
+		catchUp := world.CatchUp(time.Since(world.SavedAt), g.offlineCap)
+		summary = &catchUp
+	}
 
-	return &game
+	g.InitHUD()
+	g.ShowAwaySummary(summary)
 }
 
-// SaveGame stores the game struct in a JSON file
+// SaveGame queues the game's world to be stored in its save slot.
+// Waits if earlier saves are still being written
 func (g *Game) SaveGame() {
-	bytes, err := json.Marshal(g)
-	if err != nil {
-		log.Fatal(err)
-	}
+	bytes := g.marshalWorld()
+	g.autosaver.Save(SlotPath(g.slot), bytes)
+}
 
-	err = os.WriteFile(saveFilename, bytes, 0644)
-	if err != nil {
-		log.Fatal(err)
+// UpdateAutosave queues a save every autosaveSeconds. The save is skipped if
+// earlier saves are still being written
+func (g *Game) UpdateAutosave() {
+	g.autosaveTimer++
+	if g.autosaveTimer < autosaveSeconds*sim.TickRate {
+		return
 	}
+	g.autosaveTimer = 0
+	g.autosaver.TrySave(SlotPath(g.slot), g.marshalWorld())
 }
 
-// LoadGame returns the game struct stored in given JSON file.
-// Images are reinitialised
-func LoadGame(filePath string) *Game {
-	f, err := os.ReadFile(filePath)
+func (g *Game) marshalWorld() []byte {
+	g.world.SavedAt = time.Now()
+	bytes, err := g.world.MarshalSave()
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	var game Game
-	json.Unmarshal(f, &game)
-
-	game.tileImages = map[TileType]*ebiten.Image{}
-	game.objectImages = map[ObjectType]*ebiten.Image{}
-	game.itemImages = map[ItemType]*ebiten.Image{}
-	game.truckImages = map[TruckType]*ebiten.Image{}
-	game.InitImages()
-
-	return &game
+	return bytes
 }
 
-// Update calls the game's update functions and iterates the games tick count
+// Update passes input to the world and advances it by a tick
 func (g *Game) Update() error {
-	g.ticks += 1
-
-	// Temporary inputs before system is put in place
-	x, y := GetCursorCoordinates()
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
-		isObject, object := g.GetObjectAt(x, y)
-		if isObject {
-			g.SpawnItem(PlainD6, object)
-		}
-	}
-	if inpututil.IsKeyJustPressed(ebiten.Key1) {
-		isObject, object := g.GetObjectAt(x, y)
-		if isObject {
-			g.ObjectCount[object.Object] -= 1
-			delete(g.Objects, object.ID)
-		} else {
-			g.Buy(ConveyorBelt, x, y, South)
-		}
-	}
-	if inpututil.IsKeyJustPressed(ebiten.Key2) {
-		isObject, object := g.GetObjectAt(x, y)
-		if isObject {
-			g.ObjectCount[object.Object] -= 1
-			delete(g.Objects, object.ID)
-		} else {
-			g.SpawnObject(Builder, x, y, South)
-		}
-	}
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonMiddle) {
-		fmt.Printf("objects: ")
-		fmt.Println(g.Objects)
-		fmt.Print("items: ")
-		fmt.Println(*g.Items[1])
+	if !g.UpdateMenu() {
+		g.UpdateInput()
 	}
 
-	g.UpdateInput()
-	g.UpdateObjects()
-	g.UpdateItems()
-	g.UpdateTrucks()
-	g.UpdateCurrency()
+	g.world.Playtime += time.Second / time.Duration(sim.TickRate)
+	g.world.Tick()
+	g.UpdateHUD()
+	g.UpdateAutosave()
 	return nil
 }
 
-// Draw calls the games drag functions and passes the screen
+// Draw calls the games drag functions and passes the screen. The world is
+// drawn through the camera, under the HUD, panels and overlays
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.DrawTiles(screen)
+	g.DrawPlacement(screen)
 	g.DrawObjects(screen)
 	g.DrawItems(screen)
-	g.DrawHUD(screen)
 	g.DrawTrucks(screen)
+	g.DrawLand(screen)
+	g.DrawRoutes(screen)
+	g.DrawDockPlan(screen)
+	g.DrawHUD(screen)
+	g.DrawPanel(screen)
+	g.DrawMarket(screen)
+	g.DrawMenu(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (
@@ -236,25 +181,37 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (
 }
 
 func main() {
+	seed := flag.Int64("seed", time.Now().UnixNano(),
+		"seed for the random number generator of a new game")
+	offlineCap := flag.Duration("offline-cap", 8*time.Hour,
+		"maximum time the factory keeps running while the game is closed")
+	slot := flag.String("slot", defaultSlot, "name of the save slot to play")
+	flag.Parse()
+	if !IsSlotName(*slot) {
+		log.Fatalf("Error: slot %q must be up to %d letters, digits, '-' or '_'",
+			*slot, maxSlotNameChars)
+	}
+
+	if err := sim.LoadRegistry(os.DirFS(dataDirectory)); err != nil {
+		log.Fatal(err)
+	}
+
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Dice Factory")
 	ebiten.SetFullscreen(true)
 
-	var game *Game
-	if _, err := os.Stat(saveFilename); err != nil {
-		if os.IsNotExist(err) {
-			// file does not exist
-			game = NewGame()
-		} else {
-			// other error
-			log.Fatal(err)
-		}
-	} else {
-		game = LoadGame(saveFilename)
+	world, exists, err := LoadSlot(*slot)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !exists {
+		world = sim.NewWorld(*seed)
 	}
-	game.ticks = 60 * 7
+
+	game := NewGame(*slot, world, *offlineCap)
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
 	game.SaveGame()
+	game.autosaver.Close()
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	marketX        = screenWidth - marketWidth - tileSize
+	marketY        = upperHUDHeight
+	marketWidth    = 420
+	marketPadding  = 10
+	marketRowSize  = 56 // pixel height of each type's prices and graph
+	graphWidth     = 150
+	graphHeight    = 30
+	marketMaxFaces = 10 // faces listed per line of prices
+)
+
+var graphLine color.RGBA = color.RGBA{0xe8, 0xb8, 0x30, 0xff}
+
+// onMarket opens or closes the market overlay if the right key has been
+// pressed. The key is passed as a parameter
+func (g *Game) onMarket(key ebiten.Key) {
+	if inpututil.IsKeyJustPressed(key) {
+		g.isMarketOpen = !g.isMarketOpen
+	}
+}
+
+// DrawMarket draws the current price of each face of the dice the factory
+// makes, alongside a graph of each type's recent average price
+func (g *Game) DrawMarket(screen *ebiten.Image) {
+	if !g.isMarketOpen {
+		return
+	}
+	market := g.world.Market
+	itemTypes := g.world.ProducibleTypes()
+
+	height := marketPadding*2 + 20 + len(itemTypes)*marketRowSize
+	ebitenutil.DrawRect(screen, float64(marketX), float64(marketY),
+		marketWidth, float64(height), panelGrey)
+	ebitenutil.DebugPrintAt(screen, "Market Prices (M to close)",
+		marketX+marketPadding, marketY+marketPadding)
+
+	for index, itemType := range itemTypes {
+		x := marketX + marketPadding
+		y := marketY + marketPadding + 20 + index*marketRowSize
+
+		printString := fmt.Sprintf("%s (%ss)\n", itemType, itemType.Currency())
+		for face := 1; face <= itemType.Faces(); face++ {
+			printString += fmt.Sprintf("%d:%d ", face, market.Price(itemType, face))
+			if face%marketMaxFaces == 0 {
+				printString += "\n"
+			}
+		}
+		ebitenutil.DebugPrintAt(screen, printString, x, y)
+
+		drawPriceGraph(screen, market.History[itemType],
+			float64(marketX+marketWidth-marketPadding-graphWidth), float64(y))
+	}
+}
+
+// drawPriceGraph draws a line graph of a price history, scaled so its
+// highest price reaches the top of the graph
+func drawPriceGraph(screen *ebiten.Image, history []float64, x, y float64) {
+	ebitenutil.DrawRect(screen, x, y, graphWidth, graphHeight, buttonOff)
+	if len(history) < 2 {
+		return
+	}
+	highest := 0.0
+	for _, price := range history {
+		if price > highest {
+			highest = price
+		}
+	}
+	step := float64(graphWidth) / float64(len(history)-1)
+	for i := 1; i < len(history); i++ {
+		ebitenutil.DrawLine(screen,
+			x+step*float64(i-1), y+graphHeight*(1-history[i-1]/highest),
+			x+step*float64(i), y+graphHeight*(1-history[i]/highest),
+			graphLine)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const maxListedSlots = 9 // Slots are picked with the number keys
+
+var opaqueBlack color.RGBA = color.RGBA{0x00, 0x00, 0x00, 0xcc}
+
+// SaveMenu stores the state of the save slot menu
+type SaveMenu struct {
+	isOpen   bool
+	slots    []SaveSlot // Slots listed when the menu was opened
+	isNaming bool       // Is a new slot being named
+	name     string     // Name typed for the new slot
+}
+
+// UpdateMenu handles input for the save menu.
+// Returns true if the menu is open and used the input
+func (g *Game) UpdateMenu() bool {
+	menu := &g.menu
+	if !menu.isOpen {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			menu.isOpen = true
+			menu.slots = ListSlots()
+			if len(menu.slots) > maxListedSlots {
+				menu.slots = menu.slots[:maxListedSlots]
+			}
+		}
+		return menu.isOpen
+	}
+
+	if menu.isNaming {
+		for _, r := range ebiten.AppendInputChars(nil) {
+			if IsSlotNameChar(r) && len(menu.name) < maxSlotNameChars {
+				menu.name += string(r)
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(menu.name) > 0 {
+			menu.name = menu.name[:len(menu.name)-1]
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) && len(menu.name) > 0 {
+			// never replace an existing slot with a new world
+			if _, err := os.Stat(SlotPath(menu.name)); err == nil {
+				return true
+			}
+			g.SaveGame()
+			g.SetWorld(menu.name, sim.NewWorld(time.Now().UnixNano()))
+			g.SaveGame()
+			menu.isNaming = false
+			menu.isOpen = false
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			menu.isNaming = false
+		}
+		return true
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		menu.isOpen = false
+		return true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		menu.isNaming = true
+		menu.name = ""
+		return true
+	}
+	for index, slot := range menu.slots {
+		if !inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(index)) {
+			continue
+		}
+		menu.isOpen = false
+		if slot.Name == g.slot {
+			return true
+		}
+		world, _, err := LoadSlot(slot.Name)
+		if err != nil {
+			// keep playing the current slot if the other cannot load
+			log.Println(err)
+			return true
+		}
+		g.SaveGame()
+		g.SetWorld(slot.Name, world)
+		return true
+	}
+	return true
+}
+
+// DrawMenu draws the save menu over the game if it is open
+func (g *Game) DrawMenu(screen *ebiten.Image) {
+	menu := g.menu
+	if !menu.isOpen {
+		return
+	}
+	overlay := ebiten.NewImage(screenWidth, screenHeight)
+	overlay.Fill(opaqueBlack)
+	screen.DrawImage(overlay, &ebiten.DrawImageOptions{})
+
+	printString := fmt.Sprintf("Save Slots (playing %s)\n\n", g.slot)
+	for index, slot := range menu.slots {
+		printString += fmt.Sprintf("%d: %s\n", index+1, slot.Name)
+		printString += fmt.Sprintf("   Saved: %s\n",
+			slot.SavedAt.Local().Format("2006-01-02 15:04"))
+		printString += fmt.Sprintf("   PlainBucks: %d  GoldBucks: %d\n",
+			slot.Currencies[sim.PlainBuck], slot.Currencies[sim.GoldBuck])
+		printString += fmt.Sprintf("   Playtime: %s\n\n",
+			slot.Playtime.Round(time.Second))
+	}
+
+	if menu.isNaming {
+		printString += fmt.Sprintf("New slot name: %s_\n", menu.name)
+		printString += "Enter to create, Escape to cancel\n"
+	} else {
+		printString += "Press a number to load a slot, N for a new slot, "
+		printString += "Escape to return\n"
+	}
+
+	ebitenutil.DebugPrintAt(screen, printString, tileSize, tileSize)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	panelX             = tileSize * 2
+	panelY             = upperHUDHeight * 6
+	panelPadding       = 10
+	panelTitleHeight   = 20
+	panelButtonWidth   = 56
+	panelButtonHeight  = 24
+	panelButtonSpacing = 6
+	panelRowButtons    = 10 // most buttons drawn in a row
+)
+
+const debugCharWidth = 6 // pixel width of a character drawn by DebugPrint
+
+var (
+	panelGrey color.RGBA = color.RGBA{0x22, 0x22, 0x22, 0xee}
+	buttonOn  color.RGBA = color.RGBA{0x3a, 0x8a, 0x3a, 0xff}
+	buttonOff color.RGBA = color.RGBA{0x66, 0x66, 0x66, 0xff}
+)
+
+// Panel is an overlay of buttons used to configure an object
+type Panel struct {
+	Title       string
+	Rows        [][]PanelButton
+	ButtonWidth int // defaults to panelButtonWidth
+}
+
+// PanelButton is a labelled button in a Panel
+type PanelButton struct {
+	Label   string
+	IsOn    bool   // is the button drawn highlighted
+	OnClick func() // called when the button is clicked
+}
+
+// OpenPanel opens the configuration panel of an object.
+// Objects without settings are ignored
+func (g *Game) OpenPanel(object *sim.Object) {
+	g.ClosePanel()
+	g.panelObject = object
+	if g.BuildPanel() == nil {
+		g.panelObject = nil
+	}
+}
+
+// ClosePanel closes the open panel, if any
+func (g *Game) ClosePanel() {
+	g.panelObject = nil
+	g.isWarehouseOpen = false
+	g.dispatchDock = 0
+	g.dispatchTruck = 0
+	g.isFleetOpen = false
+	g.routeTruck = 0
+}
+
+// BuildPanel returns the panel for the open object's current settings, the
+// warehouse panel, the fleet panel, a dock's or truck's dispatch panel or a
+// truck's route panel. Returns nil if no panel is open or the open object has no
+// settings
+func (g *Game) BuildPanel() *Panel {
+	if g.isWarehouseOpen {
+		return g.BuildWarehousePanel()
+	}
+	if g.isFleetOpen {
+		return g.BuildFleetPanel()
+	}
+	if g.dispatchDock != 0 || g.dispatchTruck != 0 {
+		return g.BuildDispatchPanel()
+	}
+	if g.routeTruck != 0 {
+		return g.BuildRoutePanel()
+	}
+	object := g.panelObject
+	if object == nil {
+		return nil
+	}
+
+	switch object.Object.Behavior() {
+	case sim.SorterBehavior:
+		// buttons are wide enough for item names, so half as many fit a row
+		rowButtons := panelRowButtons / 2
+		rows := [][]PanelButton{}
+		for face := 1; face <= sim.MaxFaces(); face++ {
+			face := face
+			if (face-1)%rowButtons == 0 {
+				rows = append(rows, []PanelButton{})
+			}
+			rows[len(rows)-1] = append(rows[len(rows)-1], PanelButton{
+				Label:   fmt.Sprint(face),
+				IsOn:    object.SortFaces[face],
+				OnClick: func() { object.ToggleSortFace(face) },
+			})
+		}
+		for i, itemType := range sim.ItemTypes() {
+			itemType := itemType
+			if i%rowButtons == 0 {
+				rows = append(rows, []PanelButton{})
+			}
+			rows[len(rows)-1] = append(rows[len(rows)-1], PanelButton{
+				Label:   itemType.String(),
+				IsOn:    object.SortItems[itemType],
+				OnClick: func() { object.ToggleSortItem(itemType) },
+			})
+		}
+		return &Panel{
+			Title:       "Sorter: matching dice go forward, others right",
+			Rows:        rows,
+			ButtonWidth: panelButtonWidth * 2,
+		}
+	case sim.RerollerBehavior:
+		rows := [][]PanelButton{}
+		for below := 2; below <= sim.MaxFaces(); below++ {
+			below := below
+			if (below-2)%panelRowButtons == 0 {
+				rows = append(rows, []PanelButton{})
+			}
+			rows[len(rows)-1] = append(rows[len(rows)-1], PanelButton{
+				Label:   fmt.Sprintf("<%d", below),
+				IsOn:    object.RerollBelow == below,
+				OnClick: func() { object.RerollBelow = below },
+			})
+		}
+		return &Panel{
+			Title: "Reroller: reroll dice showing a face below",
+			Rows:  rows,
+		}
+	case sim.AssemblerBehavior:
+		recipes := []PanelButton{}
+		for _, recipe := range sim.RecipesFor(object.Object) {
+			recipe := recipe
+			recipes = append(recipes, PanelButton{
+				Label:   recipe.Name,
+				IsOn:    object.Recipe == recipe.Name,
+				OnClick: func() { object.SetRecipe(recipe.Name) },
+			})
+		}
+		return &Panel{
+			Title:       "Assembler: choose a recipe",
+			Rows:        [][]PanelButton{recipes},
+			ButtonWidth: panelButtonWidth * 2,
+		}
+	}
+	return nil
+}
+
+// buttonWidth returns the screen width of the panel's buttons
+func (p *Panel) buttonWidth() int {
+	if p.ButtonWidth > 0 {
+		return p.ButtonWidth
+	}
+	return panelButtonWidth
+}
+
+// panelButtonRect returns the screen position of a button in a panel
+func (p *Panel) panelButtonRect(row, column int) (x, y, width, height int) {
+	x = panelX + panelPadding + column*(p.buttonWidth()+panelButtonSpacing)
+	y = panelY + panelPadding + panelTitleHeight +
+		row*(panelButtonHeight+panelButtonSpacing)
+	return x, y, p.buttonWidth(), panelButtonHeight
+}
+
+// panelWidth returns the screen width of a panel
+func (p *Panel) panelWidth() int {
+	width := len(p.Title) * debugCharWidth
+	for _, buttons := range p.Rows {
+		rowWidth := len(buttons) * (p.buttonWidth() + panelButtonSpacing)
+		if rowWidth > width {
+			width = rowWidth
+		}
+	}
+	return panelPadding*2 + width
+}
+
+// panelHeight returns the screen height of a panel
+func (p *Panel) panelHeight() int {
+	return panelPadding*2 + panelTitleHeight +
+		len(p.Rows)*(panelButtonHeight+panelButtonSpacing)
+}
+
+// UpdatePanel handles clicks on the open panel. Clicking a button runs it,
+// and clicking outside the panel closes it.
+// Returns true if a panel is open and used the input
+func (g *Game) UpdatePanel() bool {
+	panel := g.BuildPanel()
+	if panel == nil {
+		g.ClosePanel()
+		return false
+	}
+	if !inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		return true
+	}
+
+	cursorX, cursorY := ebiten.CursorPosition()
+	if cursorX < panelX || cursorX >= panelX+panel.panelWidth() ||
+		cursorY < panelY || cursorY >= panelY+panel.panelHeight() {
+		g.ClosePanel()
+		return true
+	}
+
+	for row, buttons := range panel.Rows {
+		for column, button := range buttons {
+			x, y, width, height := panel.panelButtonRect(row, column)
+			if cursorX >= x && cursorX < x+width &&
+				cursorY >= y && cursorY < y+height && button.OnClick != nil {
+				button.OnClick()
+				return true
+			}
+		}
+	}
+	return true
+}
+
+// DrawPanel draws the open panel, if any
+func (g *Game) DrawPanel(screen *ebiten.Image) {
+	panel := g.BuildPanel()
+	if panel == nil {
+		return
+	}
+
+	ebitenutil.DrawRect(screen, float64(panelX), float64(panelY),
+		float64(panel.panelWidth()), float64(panel.panelHeight()), panelGrey)
+	ebitenutil.DebugPrintAt(screen, panel.Title,
+		panelX+panelPadding, panelY+panelPadding)
+
+	for row, buttons := range panel.Rows {
+		for column, button := range buttons {
+			x, y, width, height := panel.panelButtonRect(row, column)
+			fill := buttonOff
+			if button.IsOn {
+				fill = buttonOn
+			}
+			ebitenutil.DrawRect(screen, float64(x), float64(y),
+				float64(width), float64(height), fill)
+			ebitenutil.DebugPrintAt(screen, button.Label, x+4, y+4)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const routeButtonWidth = 150
+
+// routeColors are the colours routes are drawn in, picked by truck number
+var routeColors = []color.RGBA{
+	{0xe8, 0xb8, 0x30, 0xff},
+	{0x3a, 0xc8, 0xe8, 0xff},
+	{0xe8, 0x5a, 0x5a, 0xff},
+	{0x8a, 0xe8, 0x5a, 0xff},
+	{0xc8, 0x7a, 0xe8, 0xff},
+}
+
+// onRoutes shows or hides the route overlay of every truck if the right key
+// has been pressed. The key is passed as a parameter
+func (g *Game) onRoutes(key ebiten.Key) {
+	if inpututil.IsKeyJustPressed(key) {
+		g.isRoutesShown = !g.isRoutesShown
+	}
+}
+
+// OpenRoutePanel opens the route panel of a truck
+func (g *Game) OpenRoutePanel(truck *sim.Truck) {
+	g.ClosePanel()
+	g.routeTruck = truck.ID
+}
+
+// deliveryLabel returns where a route delivers to
+func (g *Game) deliveryLabel(route sim.Route) string {
+	switch route.Delivery {
+	case sim.DeliverWarehouse:
+		for index, warehouse := range g.world.SortedWarehouses() {
+			if warehouse.ID == route.DeliverTo {
+				return fmt.Sprintf("#%d %s", index+1, warehouse.Warehouse)
+			}
+		}
+	case sim.DeliverOrder:
+		if order, exists := g.world.Orders[route.DeliverTo]; exists {
+			return fmt.Sprintf("order for %s", order)
+		}
+	}
+	return "any warehouse"
+}
+
+// routeLabel returns a truck's stops and where it delivers, in order
+func (g *Game) routeLabel(truck *sim.Truck) string {
+	label := ""
+	for _, id := range truck.Route.Stops {
+		label += g.dockLabel(id) + " > "
+	}
+	return label + g.deliveryLabel(truck.Route)
+}
+
+// BuildRoutePanel returns a panel of the open truck's route: its stops,
+// docks to add as stops and where it delivers
+func (g *Game) BuildRoutePanel() *Panel {
+	world := g.world
+	truck, exists := world.Trucks[g.routeTruck]
+	if !exists {
+		return nil
+	}
+	route := truck.Route
+
+	stops := []PanelButton{{Label: "Stops:"}}
+	for index, id := range route.Stops {
+		index := index
+		stops = append(stops, PanelButton{
+			Label:   fmt.Sprintf("%d: %s", index+1, g.dockLabel(id)),
+			IsOn:    index == truck.Stop,
+			OnClick: func() { truck.RemoveStop(index) },
+		})
+	}
+
+	adds := []PanelButton{{Label: "Add stop:"}}
+	for _, dock := range world.SortedDocks() {
+		dock := dock
+		adds = append(adds, PanelButton{
+			Label:   g.dockLabel(dock.ID),
+			OnClick: func() { truck.AddStop(dock) },
+		})
+	}
+
+	warehouses := []PanelButton{
+		{Label: "Deliver to:"},
+		{
+			Label:   "Any Warehouse",
+			IsOn:    route.Delivery == sim.DeliverAnywhere,
+			OnClick: func() { truck.SetDelivery(sim.DeliverAnywhere, 0) },
+		},
+	}
+	for index, warehouse := range world.SortedWarehouses() {
+		warehouse := warehouse
+		warehouses = append(warehouses, PanelButton{
+			Label: fmt.Sprintf("#%d %s", index+1, warehouse.Warehouse),
+			IsOn: route.Delivery == sim.DeliverWarehouse &&
+				route.DeliverTo == warehouse.ID,
+			OnClick: func() { truck.SetDelivery(sim.DeliverWarehouse, warehouse.ID) },
+		})
+	}
+
+	orders := []PanelButton{{Label: "Or to order:"}}
+	for _, order := range world.SortedOrders() {
+		order := order
+		orders = append(orders, PanelButton{
+			Label:   order.String(),
+			IsOn:    route.Delivery == sim.DeliverOrder && route.DeliverTo == order.ID,
+			OnClick: func() { truck.SetDelivery(sim.DeliverOrder, order.ID) },
+		})
+	}
+
+	return &Panel{
+		Title: fmt.Sprintf("%s route: click a stop to remove it",
+			g.truckLabel(truck)),
+		Rows:        [][]PanelButton{stops, adds, warehouses, orders},
+		ButtonWidth: routeButtonWidth,
+	}
+}
+
+// DrawRoutes draws the route of the truck whose route panel is open, or of
+// every truck while the overlay is shown. Lines join the bays of each stop
+// in turn, and the last stop is labelled with where the truck delivers
+func (g *Game) DrawRoutes(screen *ebiten.Image) {
+	for index, truck := range g.world.SortedTrucks() {
+		if !g.isRoutesShown && truck.ID != g.routeTruck {
+			continue
+		}
+		lineColor := routeColors[index%len(routeColors)]
+
+		var lastX, lastY float64
+		for stop, id := range truck.Route.Stops {
+			dock, exists := g.world.Docks[id]
+			if !exists {
+				continue
+			}
+			x, y := g.camera.ToScreen(
+				float64((dock.TargetX*2+dock.Width)*tileSize/2),
+				float64((dock.TargetY*2+dock.Height)*tileSize/2))
+			if stop > 0 {
+				ebitenutil.DrawLine(screen, lastX, lastY, x, y, lineColor)
+			}
+			ebitenutil.DrawRect(screen, x-4, y-4, 8, 8, lineColor)
+			label := fmt.Sprintf("#%d stop %d", index+1, stop+1)
+			if stop == len(truck.Route.Stops)-1 {
+				label += ", then " + g.deliveryLabel(truck.Route)
+			}
+			ebitenutil.DebugPrintAt(screen, label, int(x)+6, int(y)+6+index*14)
+			lastX, lastY = x, y
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Rolls71/dice-factory/sim"
+)
+
+const (
+	savesDirectory   string = "saves"
+	defaultSlot      string = "default"
+	legacySaveFile   string = "save.json" // used before save slots existed
+	autosaveSeconds  int    = 60
+	saveBackupCount  int    = 3
+	saveQueueLength  int    = 4
+	maxSlotNameChars int    = 20
+)
+
+// SaveSlot describes a named save file
+type SaveSlot struct {
+	Name       string
+	SavedAt    time.Time
+	Currencies map[sim.CurrencyType]uint64
+	Playtime   time.Duration
+}
+
+// SlotPath returns the file a save slot is stored in
+func SlotPath(name string) string {
+	return filepath.Join(savesDirectory, name+".json")
+}
+
+// IsSlotNameChar returns true if the rune may be used in a slot name
+func IsSlotNameChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9') ||
+		r == '-' || r == '_'
+}
+
+// IsSlotName returns true if the name can be used for a save slot: up to
+// maxSlotNameChars letters, digits, '-' or '_'
+func IsSlotName(name string) bool {
+	if name == "" || len(name) > maxSlotNameChars {
+		return false
+	}
+	for _, r := range name {
+		if !IsSlotNameChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListSlots returns every save slot, most recently saved first.
+// Slots that cannot be loaded are skipped.
+func ListSlots() []SaveSlot {
+	paths, err := filepath.Glob(filepath.Join(savesDirectory, "*.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	slots := []SaveSlot{}
+	for _, path := range paths {
+		world, err := LoadGame(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		slots = append(slots, SaveSlot{
+			Name:       strings.TrimSuffix(filepath.Base(path), ".json"),
+			SavedAt:    world.SavedAt,
+			Currencies: world.Currencies,
+			Playtime:   world.Playtime,
+		})
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].SavedAt.After(slots[j].SavedAt)
+	})
+	return slots
+}
+
+// LoadGame returns the world stored in given JSON file.
+// Older save versions are migrated to the current version.
+func LoadGame(filePath string) (*sim.World, error) {
+	f, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	world, err := sim.UnmarshalSave(f)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", filePath, err)
+	}
+	return world, nil
+}
+
+// backupPath returns the file of a save's numbered backup
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
+
+// LoadSlot returns the world stored in a save slot. A slot whose save is
+// missing or can't be loaded is loaded from its newest backup that can be.
+// Returns false if the slot does not exist yet.
+func LoadSlot(name string) (*sim.World, bool, error) {
+	paths := []string{SlotPath(name)}
+	for i := 1; i <= saveBackupCount; i++ {
+		paths = append(paths, backupPath(SlotPath(name), i))
+	}
+
+	var firstErr error
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		world, err := LoadGame(path)
+		if err == nil {
+			return world, true, nil
+		}
+		log.Println(err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, false, firstErr
+	}
+
+	// fall back to the save made before slots existed
+	if name != defaultSlot {
+		return nil, false, nil
+	}
+	if _, err := os.Stat(legacySaveFile); os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	world, err := LoadGame(legacySaveFile)
+	if err != nil {
+		return nil, false, err
+	}
+	return world, true, nil
+}
+
+// WriteSave atomically replaces a save file. The data is written to a
+// temporary file which is renamed over the save, after the previous
+// saveBackupCount versions are rotated into numbered backups. The save is
+// linked or copied into the first backup rather than moved, so it exists
+// until the rename replaces it.
+func WriteSave(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp := path + ".tmp"
+	file, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	// rotate backups, dropping the oldest
+	for i := saveBackupCount - 1; i > 0; i-- {
+		from := backupPath(path, i)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, backupPath(path, i+1)); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		if err := copySave(path, backupPath(path, 1)); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(temp, path)
+}
+
+// copySave hard links a save file to a new path, or copies it if it can't
+// be linked. Anything already at the new path is replaced
+func copySave(from, to string) error {
+	if err := os.Remove(to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(from, to); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(to, data, 0644)
+}
+
+type saveRequest struct {
+	path string
+	data []byte
+}
+
+// Autosaver writes saves on a background goroutine so the game does not
+// stall on disk access. Saves are written in the order they are queued.
+type Autosaver struct {
+	requests chan saveRequest
+	done     chan struct{}
+}
+
+// NewAutosaver constructs an Autosaver and starts its goroutine
+func NewAutosaver() *Autosaver {
+	a := &Autosaver{
+		requests: make(chan saveRequest, saveQueueLength),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		for request := range a.requests {
+			if err := WriteSave(request.path, request.data); err != nil {
+				log.Println(err)
+			}
+		}
+		close(a.done)
+	}()
+	return a
+}
+
+// Save queues a save, waiting if the queue is full
+func (a *Autosaver) Save(path string, data []byte) {
+	a.requests <- saveRequest{path, data}
+}
+
+// TrySave queues a save unless the queue is full.
+// Returns false if the save was dropped
+func (a *Autosaver) TrySave(path string, data []byte) bool {
+	select {
+	case a.requests <- saveRequest{path, data}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close waits for queued saves to be written and stops the goroutine
+func (a *Autosaver) Close() {
+	close(a.requests)
+	<-a.done
+}
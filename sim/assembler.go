@@ -0,0 +1,97 @@
+package sim
+
+// SetRecipe selects the recipe an Assembler makes. Dice already taken into
+// the assembler are kept if the new recipe uses them.
+func (o *Object) SetRecipe(name string) {
+	recipe, isRecipe := RecipeNamed(name)
+	if !isRecipe || recipe.Machine != o.Object {
+		return
+	}
+	o.Recipe = name
+	o.AssembleTicks = 0
+	for itemType, count := range o.AssembleBuffer {
+		needed := recipe.Needs(itemType)
+		if count > needed {
+			o.AssembleBuffer[itemType] = needed
+		}
+		if o.AssembleBuffer[itemType] == 0 {
+			delete(o.AssembleBuffer, itemType)
+		}
+	}
+}
+
+// Needs returns how many items of a type the recipe uses
+func (r *Recipe) Needs(itemType ItemType) int {
+	needed := 0
+	for _, input := range r.Inputs {
+		if input.Item == itemType {
+			needed += input.Count
+		}
+	}
+	return needed
+}
+
+// isAssembled returns true if an Assembler holds every ingredient of recipe
+func (o *Object) isAssembled(recipe *Recipe) bool {
+	for _, input := range recipe.Inputs {
+		if o.AssembleBuffer[input.Item] < recipe.Needs(input.Item) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssembleItemOn takes ingredients of an Assembler's recipe into its buffer
+// and passes other items forward. Once every ingredient is held, the
+// assembler spends a cycle before sending the product forward.
+func (w *World) AssembleItemOn(object *Object) {
+	recipe, isRecipe := RecipeNamed(object.Recipe)
+	if !isRecipe || recipe.Machine != object.Object {
+		w.MoveItemOn(object)
+		return
+	}
+
+	if object.isAssembled(recipe) {
+		if object.AssembleTicks < w.cycleTicks(object) {
+			object.AssembleTicks++
+		} else if w.outputProduct(object, recipe) {
+			object.AssembleBuffer = map[ItemType]int{}
+			object.AssembleTicks = 0
+		}
+	}
+
+	isItemOn, item := w.IsItemOn(object)
+	if !isItemOn {
+		return
+	}
+	if recipe.Needs(item.Item) == 0 {
+		w.MoveItemOn(object)
+		return
+	}
+	// surplus ingredients wait until the product has been sent
+	if object.AssembleBuffer[item.Item] < recipe.Needs(item.Item) {
+		if object.AssembleBuffer == nil {
+			object.AssembleBuffer = map[ItemType]int{}
+		}
+		object.AssembleBuffer[item.Item]++
+		w.DeleteItem(item)
+	}
+}
+
+// outputProduct sends a recipe's product out of an Assembler.
+// Returns false if the assembler's output is blocked
+func (w *World) outputProduct(object *Object, recipe *Recipe) bool {
+	isItemMoveable, neighbor := w.IsItemMoveable(object)
+	if !isItemMoveable {
+		return false
+	}
+
+	product := w.SpawnItem(recipe.Output, object)
+	product.Currency = recipe.Output.Currency()
+	if !w.MoveItemTo(product, neighbor) {
+		w.DeleteItem(product)
+		return false
+	}
+	w.Produced++
+	return true
+}
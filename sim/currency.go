@@ -0,0 +1,61 @@
+package sim
+
+import "fmt"
+
+type CurrencyType int
+
+const (
+	PlainBuck = iota
+	GoldBuck
+)
+
+func (c CurrencyType) String() string {
+	for name, currency := range currencyNames {
+		if currency == c {
+			return name
+		}
+	}
+	return fmt.Sprintf("CurrencyType(%d)", int(c))
+}
+
+// Cost returns the calculated cost of an ObjectType.
+// Defaults to the max uint64 value.
+func (w *World) Cost(object ObjectType) (CurrencyType, uint64) {
+	cost := registry.object(object).Cost
+	if cost == nil {
+		return PlainBuck, maxUint64
+	}
+	return cost.Currency, cost.price(w.ObjectCount[object])
+}
+
+// Pay subtracts given value from DicePoints unless value is less than
+// DicePoints. Returns true if the payment was successful
+func (w *World) Pay(currencyType CurrencyType, value uint64) bool {
+	if w.Currencies[currencyType] >= value {
+		w.Currencies[currencyType] -= value
+		return true
+	}
+	return false
+}
+
+// Buy will attempt to Pay for an object, and the foundation its tile needs,
+// and spawn it if successful. Nothing is bought if the object can't be
+// placed on the tile or both can't be afforded
+func (w *World) Buy(objectType ObjectType, x, y int, objectFacing CardinalDir) {
+	if !w.CanPlace(x, y) {
+		return
+	}
+	currency, cost := w.Cost(objectType)
+	foundationCurrency, foundation := w.FoundationCost(x, y)
+	if currency == foundationCurrency {
+		if cost > maxUint64-foundation ||
+			w.Currencies[currency] < cost+foundation {
+			return
+		}
+	} else if w.Currencies[foundationCurrency] < foundation {
+		return
+	}
+	if w.Pay(currency, cost) && w.BuildFoundation(x, y) {
+		w.SpawnObject(objectType, x, y, objectFacing)
+	}
+}
@@ -0,0 +1,141 @@
+package sim
+
+type DispatchMode int
+
+const (
+	ManualDispatch   DispatchMode = iota // Leave when clicked.
+	DispatchWhenFull                     // Leave once the truck is full.
+	DispatchAfter                        // Leave Seconds after loading starts.
+	DispatchOnMix                        // Leave once the Mix is loaded.
+	DispatchSchedule                     // Leave every Seconds of play.
+)
+
+// DispatchModes lists every DispatchMode
+var DispatchModes = []DispatchMode{
+	ManualDispatch,
+	DispatchWhenFull,
+	DispatchAfter,
+	DispatchOnMix,
+	DispatchSchedule,
+}
+
+// DispatchSeconds are the waits a DispatchRule can be set to
+var DispatchSeconds = []int{10, 30, 60, 120, 300}
+
+// mixSteps are the numbers of a type a DispatchRule's Mix can ask for
+var mixSteps = []int{0, 1, 2, 5, 10}
+
+func (m DispatchMode) String() string {
+	switch m {
+	case ManualDispatch:
+		return "Manual"
+	case DispatchWhenFull:
+		return "When Full"
+	case DispatchAfter:
+		return "After Wait"
+	case DispatchOnMix:
+		return "On Mix"
+	case DispatchSchedule:
+		return "Schedule"
+	}
+	return "Unknown Dispatch"
+}
+
+// DispatchRule decides when a truck leaves a dock without being clicked
+type DispatchRule struct {
+	Dispatch DispatchMode
+	Seconds  int                 // wait used by DispatchAfter and DispatchSchedule
+	Mix      map[ItemType]uint64 // dice of each type wanted by DispatchOnMix
+}
+
+// NewDispatchRule constructs a DispatchRule that waits to be clicked
+func NewDispatchRule() DispatchRule {
+	return DispatchRule{
+		Dispatch: ManualDispatch,
+		Seconds:  DispatchSeconds[0],
+		Mix:      map[ItemType]uint64{},
+	}
+}
+
+// CycleMix sets the number of a type the rule's Mix asks for to the next
+// number in mixSteps
+func (r *DispatchRule) CycleMix(itemType ItemType) {
+	if r.Mix == nil {
+		r.Mix = map[ItemType]uint64{}
+	}
+	next := nextStep(mixSteps, int(r.Mix[itemType]))
+	if next == 0 {
+		delete(r.Mix, itemType)
+	} else {
+		r.Mix[itemType] = uint64(next)
+	}
+}
+
+// isMixLoaded returns true if a storage holds every die the Mix asks for.
+// An empty Mix is never loaded
+func (r *DispatchRule) isMixLoaded(storage *Storage) bool {
+	if len(r.Mix) == 0 {
+		return false
+	}
+	for itemType, count := range r.Mix {
+		held := uint64(0)
+		for _, faceCount := range storage.Dice[itemType] {
+			held += faceCount
+		}
+		if held < count {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a copy of the rule that can be changed without changing it
+func (r *DispatchRule) clone() DispatchRule {
+	mix := map[ItemType]uint64{}
+	for itemType, count := range r.Mix {
+		mix[itemType] = count
+	}
+	return DispatchRule{Dispatch: r.Dispatch, Seconds: r.Seconds, Mix: mix}
+}
+
+// DispatchRuleOf returns the rule that decides when a truck leaves: its own
+// rule if it has one, otherwise its dock's
+func (w *World) DispatchRuleOf(t *Truck) *DispatchRule {
+	if t.Dispatch != nil {
+		return t.Dispatch
+	}
+	return &w.Docks[t.DockID].Dispatch
+}
+
+// OwnDispatchRule gives a truck a rule of its own, starting as a copy of its
+// dock's, if it doesn't already have one. Returns the truck's rule
+func (w *World) OwnDispatchRule(t *Truck) *DispatchRule {
+	if t.Dispatch == nil {
+		rule := w.Docks[t.DockID].Dispatch.clone()
+		t.Dispatch = &rule
+	}
+	return t.Dispatch
+}
+
+// isDispatchDue returns true if a loading truck's dispatch rule says it
+// should leave. Empty trucks never leave, and a truck waiting on a mix
+// leaves once full so it can't wait forever
+func (w *World) isDispatchDue(t *Truck) bool {
+	storage := t.Storage
+	if storage.Count == 0 {
+		return false
+	}
+	isFull := storage.Count >= storage.Capacity
+	rule := w.DispatchRuleOf(t)
+	switch rule.Dispatch {
+	case DispatchWhenFull:
+		return isFull
+	case DispatchAfter:
+		return t.LoadingTicks >= uint64(rule.Seconds*TickRate)
+	case DispatchOnMix:
+		return isFull || rule.isMixLoaded(storage)
+	case DispatchSchedule:
+		return w.Ticks%uint64(rule.Seconds*TickRate) == 0
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package sim
+
+import "testing"
+
+// TestTruckDispatchOverridesDock checks a truck's own dispatch rule is used
+// over its dock's, and that trucks without one follow the dock
+func TestTruckDispatchOverridesDock(t *testing.T) {
+	world := NewWorld(1)
+	truck := world.SortedTrucks()[0]
+	dock := world.Docks[truck.DockID]
+	truck.Storage.StoreDie(PlainD6, 1)
+	truck.LoadingTicks = uint64(DispatchSeconds[0] * TickRate)
+
+	dock.Dispatch.Dispatch = DispatchAfter
+	if !world.isDispatchDue(truck) {
+		t.Fatal("truck without a rule didn't follow its dock's")
+	}
+
+	world.OwnDispatchRule(truck).Dispatch = ManualDispatch
+	if world.isDispatchDue(truck) {
+		t.Fatal("truck followed its dock's rule over its own")
+	}
+	if dock.Dispatch.Dispatch != DispatchAfter {
+		t.Fatal("changing the truck's rule changed its dock's")
+	}
+
+	data, err := world.MarshalSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := UnmarshalSave(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := loaded.Trucks[truck.ID].Dispatch
+	if rule == nil || rule.Dispatch != ManualDispatch {
+		t.Fatal("truck's own rule was not saved")
+	}
+}
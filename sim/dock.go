@@ -0,0 +1,288 @@
+package sim
+
+import (
+	"log"
+	"math"
+	"math/bits"
+)
+
+const (
+	dockLength     = 4    // Tiles a dock's bay reaches into the map.
+	dockWidth      = 2    // Tiles of the map edge a dock's bay covers.
+	dockDriveIn    = 5    // Tiles off the map trucks arrive from.
+	dockCost       = 2000 // PlainBucks for the first dock built.
+	dockCollectors = 2    // Collectors built at the back of a dock's bay.
+)
+
+// Dock is a truck bay beside a group of collectors. Trucks assigned to a dock
+// queue up and take turns arriving at the bay to be loaded
+type Dock struct {
+	ID               uint64
+	CollectorIDs     []uint64     // IDs of the Collector objects loading trucks
+	SpawnX, SpawnY   int          // tile trucks arrive from and leave to
+	TargetX, TargetY int          // tile of the bay trucks are loaded at
+	Width, Height    int          // size of the bay in tiles
+	Queue            []uint64     // IDs of the assigned trucks, the one at the bay first
+	Dispatch         DispatchRule // decides when trucks leave the bay by themselves
+}
+
+// SpawnDock constructs a new dock with an empty queue
+func (w *World) SpawnDock(
+	collectorIDs []uint64,
+	spawnX, spawnY int,
+	targetX, targetY int,
+	width, height int) *Dock {
+	if len(collectorIDs) < 1 {
+		log.Fatal("Error: Dock must have at least one collector")
+	}
+
+	dock := &Dock{
+		ID:           w.NextID(),
+		CollectorIDs: collectorIDs,
+		SpawnX:       spawnX,
+		SpawnY:       spawnY,
+		TargetX:      targetX,
+		TargetY:      targetY,
+		Width:        width,
+		Height:       height,
+		Queue:        []uint64{},
+		Dispatch:     NewDispatchRule(),
+	}
+	w.Docks[dock.ID] = dock
+	return dock
+}
+
+// DockPlan is where a dock built on an edge of the map would go
+type DockPlan struct {
+	Edge          CardinalDir // edge of the map trucks arrive from
+	X, Y          int         // top left tile of the bay
+	Width, Height int         // size of the bay in tiles
+}
+
+// PlanDock returns the dock that would be built on an edge of the map,
+// nearest the tile along the edge at offset
+func PlanDock(edge CardinalDir, offset int) DockPlan {
+	plan := DockPlan{Edge: edge, Width: dockLength, Height: dockWidth}
+	if edge == North || edge == South {
+		plan.Width, plan.Height = dockWidth, dockLength
+	}
+	clamp := func(offset, size, stage int) int {
+		return int(math.Max(0, math.Min(float64(offset), float64(stage-size))))
+	}
+	plan.X = clamp(offset, plan.Width, StageSizeX)
+	plan.Y = clamp(offset, plan.Height, StageSizeY)
+	switch edge {
+	case West:
+		plan.X = 0
+	case East:
+		plan.X = StageSizeX - dockLength
+	case North:
+		plan.Y = 0
+	case South:
+		plan.Y = StageSizeY - dockLength
+	}
+	return plan
+}
+
+// PlanDockNear returns the dock that would be built on the edge of the map
+// nearest a tile
+func PlanDockNear(x, y int) DockPlan {
+	distances := map[CardinalDir]int{
+		West:  x,
+		East:  StageSizeX - 1 - x,
+		North: y,
+		South: StageSizeY - 1 - y,
+	}
+	nearest := West
+	for _, edge := range []CardinalDir{East, North, South} {
+		if distances[edge] < distances[nearest] {
+			nearest = edge
+		}
+	}
+	if nearest == West || nearest == East {
+		return PlanDock(nearest, y)
+	}
+	return PlanDock(nearest, x)
+}
+
+// collectorTiles returns the tiles of the collectors at the back of the bay
+func (p DockPlan) collectorTiles() [][2]int {
+	tiles := [][2]int{}
+	for i := 0; i < dockCollectors; i++ {
+		switch p.Edge {
+		case West:
+			tiles = append(tiles, [2]int{p.X + dockLength - 1, p.Y + i})
+		case East:
+			tiles = append(tiles, [2]int{p.X, p.Y + i})
+		case North:
+			tiles = append(tiles, [2]int{p.X + i, p.Y + dockLength - 1})
+		case South:
+			tiles = append(tiles, [2]int{p.X + i, p.Y})
+		}
+	}
+	return tiles
+}
+
+// spawnTile returns the tile off the map that trucks arrive from
+func (p DockPlan) spawnTile() (int, int) {
+	switch p.Edge {
+	case West:
+		return p.X - dockDriveIn, p.Y
+	case East:
+		return p.X + dockDriveIn, p.Y
+	case North:
+		return p.X, p.Y - dockDriveIn
+	}
+	return p.X, p.Y + dockDriveIn
+}
+
+// overlaps returns true if the plan's bay covers any tile of a dock's bay
+func (p DockPlan) overlaps(dock *Dock) bool {
+	return p.X < dock.TargetX+dock.Width && dock.TargetX < p.X+p.Width &&
+		p.Y < dock.TargetY+dock.Height && dock.TargetY < p.Y+p.Height
+}
+
+// CanBuildDock returns true if a planned dock's bay is empty, on owned land
+// and does not overlap another dock's bay
+func (w *World) CanBuildDock(plan DockPlan) bool {
+	for _, dock := range w.Docks {
+		if plan.overlaps(dock) {
+			return false
+		}
+	}
+	for x := plan.X; x < plan.X+plan.Width; x++ {
+		for y := plan.Y; y < plan.Y+plan.Height; y++ {
+			if !w.CanPlace(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DockCost returns the cost of building another dock.
+// Each dock built beyond the starting dock doubles the cost of the next
+func (w *World) DockCost() (CurrencyType, uint64) {
+	built := len(w.Docks) - 1
+	if built < 0 {
+		built = 0
+	}
+	if built >= 64-bits.Len64(dockCost) {
+		return PlainBuck, maxUint64
+	}
+	return PlainBuck, dockCost << built
+}
+
+// PlanCost returns the cost of building a planned dock: the cost of another
+// dock and of the foundations its collectors need, both paid in PlainBucks
+func (w *World) PlanCost(plan DockPlan) (CurrencyType, uint64) {
+	currency, cost := w.DockCost()
+	for _, tile := range plan.collectorTiles() {
+		_, foundation := w.FoundationCost(tile[0], tile[1])
+		if cost > maxUint64-foundation {
+			return currency, maxUint64
+		}
+		cost += foundation
+	}
+	return currency, cost
+}
+
+// BuildDock constructs the collectors and bay of a planned dock.
+// Returns nil if the dock can't be built there
+func (w *World) BuildDock(plan DockPlan) *Dock {
+	if !w.CanBuildDock(plan) {
+		return nil
+	}
+	collectorIDs := []uint64{}
+	for _, tile := range plan.collectorTiles() {
+		collector := w.SpawnObject(Collector, tile[0], tile[1], plan.Edge.Opposite())
+		collectorIDs = append(collectorIDs, collector.ID)
+	}
+	spawnX, spawnY := plan.spawnTile()
+	return w.SpawnDock(collectorIDs, spawnX, spawnY, plan.X, plan.Y,
+		plan.Width, plan.Height)
+}
+
+// BuyDock will attempt to Pay for a planned dock and the foundations its
+// collectors need, and build it if it can be built there.
+// Returns nil if the dock was not built
+func (w *World) BuyDock(plan DockPlan) *Dock {
+	currency, cost := w.PlanCost(plan)
+	if !w.CanBuildDock(plan) || w.Currencies[currency] < cost {
+		return nil
+	}
+	// the dock and foundations are all paid in PlainBucks and were afforded
+	// together above, so neither payment fails part way
+	if !w.Pay(w.DockCost()) {
+		return nil
+	}
+	for _, tile := range plan.collectorTiles() {
+		if !w.BuildFoundation(tile[0], tile[1]) {
+			return nil
+		}
+	}
+	return w.BuildDock(plan)
+}
+
+// SortedDocks returns the docks, oldest first
+func (w *World) SortedDocks() []*Dock {
+	docks := []*Dock{}
+	for _, id := range sortedIDs(w.Docks) {
+		docks = append(docks, w.Docks[id])
+	}
+	return docks
+}
+
+// existingCollectors returns the IDs of the collectors that still exist,
+// dropping any whose collector was deleted by an older version
+func (w *World) existingCollectors(ids []uint64) []uint64 {
+	existing := []uint64{}
+	for _, id := range ids {
+		collector, exists := w.Objects[id]
+		if exists && collector.Object.Behavior() == CollectorBehavior {
+			existing = append(existing, id)
+		}
+	}
+	return existing
+}
+
+// isDockCollector returns true if the object is a collector of a dock
+func (w *World) isDockCollector(object *Object) bool {
+	for _, dock := range w.Docks {
+		for _, id := range dock.CollectorIDs {
+			if id == object.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAtBay returns true if the truck is first in its dock's queue
+func (d *Dock) isAtBay(t *Truck) bool {
+	return len(d.Queue) > 0 && d.Queue[0] == t.ID
+}
+
+// removeFromQueue removes a truck from the dock's queue, if it is queued
+func (d *Dock) removeFromQueue(t *Truck) {
+	for index, id := range d.Queue {
+		if id == t.ID {
+			d.Queue = append(d.Queue[:index], d.Queue[index+1:]...)
+			return
+		}
+	}
+}
+
+// AssignTruck sets a truck's route to a single dock and moves it to the back
+// of the dock's queue. Only trucks that are off the map, waiting for their
+// turn or about to arrive, can be moved.
+// Returns false if the truck is on the map
+func (w *World) AssignTruck(t *Truck, dock *Dock) bool {
+	if t.PercentComplete > 0 || t.IsExiting {
+		return false
+	}
+	t.Route.Stops = []uint64{dock.ID}
+	t.Stop = 0
+	w.parkAt(t, dock)
+	return true
+}
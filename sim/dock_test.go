@@ -0,0 +1,144 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestDeleteDockCollector checks a dock's collectors can't be deleted
+func TestDeleteDockCollector(t *testing.T) {
+	world := NewWorld(1)
+	dock := world.SortedDocks()[0]
+	collector := world.Objects[dock.CollectorIDs[0]]
+	if world.DeleteObject(collector) {
+		t.Fatal("deleted a dock's collector")
+	}
+	if _, exists := world.Objects[collector.ID]; !exists {
+		t.Fatal("dock's collector was removed")
+	}
+}
+
+// saveWithout returns a save of the world with the given objects removed
+func saveWithout(t *testing.T, world *World, ids ...uint64) []byte {
+	data, err := world.MarshalSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var save map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&save); err != nil {
+		t.Fatal(err)
+	}
+	removed := map[uint64]bool{}
+	for _, id := range ids {
+		removed[id] = true
+	}
+	objects := []any{}
+	for _, value := range save["Objects"].([]any) {
+		id, _ := jsonUint(value.(map[string]any)["ID"])
+		if !removed[id] {
+			objects = append(objects, value)
+		}
+	}
+	save["Objects"] = objects
+	if data, err = json.Marshal(save); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestLoadMissingCollector checks a save whose dock lost a collector, as
+// older versions allowed, still loads without the missing collector
+func TestLoadMissingCollector(t *testing.T) {
+	world := NewWorld(1)
+	dock := world.SortedDocks()[0]
+	missing := dock.CollectorIDs[0]
+	data := saveWithout(t, world, missing)
+
+	loaded, err := UnmarshalSave(data)
+	if err != nil {
+		t.Fatalf("loading save with a missing collector: %v", err)
+	}
+	for _, id := range loaded.Docks[dock.ID].CollectorIDs {
+		if id == missing {
+			t.Fatal("dock kept the missing collector")
+		}
+	}
+	for _, truck := range loaded.Trucks {
+		for _, id := range truck.CollectorIDs {
+			if id == missing {
+				t.Fatalf("truck %d kept the missing collector", truck.ID)
+			}
+		}
+	}
+}
+
+// TestLoadDockWithoutCollectors checks a save whose dock lost every
+// collector is rejected, as its trucks could never load
+func TestLoadDockWithoutCollectors(t *testing.T) {
+	world := NewWorld(1)
+	dock := world.SortedDocks()[0]
+	data := saveWithout(t, world, dock.CollectorIDs...)
+	if _, err := UnmarshalSave(data); err == nil {
+		t.Fatal("loaded a dock without collectors")
+	}
+}
+
+// TestBuyDockOnRock checks a dock's collectors pay for foundations on rock
+func TestBuyDockOnRock(t *testing.T) {
+	rock, _ := registry.tileNamed("Rock")
+	foundationTile, _ := registry.tileNamed("Foundation")
+	world := NewWorld(1)
+	plan := PlanDock(North, 10)
+	for _, tile := range plan.collectorTiles() {
+		world.TileStage[tile[1]][tile[0]] = int(rock)
+	}
+	_, dockCost := world.DockCost()
+	_, cost := world.PlanCost(plan)
+	_, foundation := world.FoundationCost(plan.collectorTiles()[0][0],
+		plan.collectorTiles()[0][1])
+	if cost != dockCost+foundation*dockCollectors {
+		t.Fatalf("plan costs %d, want %d", cost, dockCost+foundation*dockCollectors)
+	}
+
+	world.Currencies[PlainBuck] = cost - 1
+	if world.BuyDock(plan) != nil {
+		t.Fatal("bought a dock without affording its foundations")
+	}
+	world.Currencies[PlainBuck] = cost
+	if world.BuyDock(plan) == nil {
+		t.Fatal("dock not bought")
+	}
+	if world.Currencies[PlainBuck] != 0 {
+		t.Fatalf("%d PlainBucks left, want 0", world.Currencies[PlainBuck])
+	}
+	for _, tile := range plan.collectorTiles() {
+		if world.TileAt(tile[0], tile[1]) != foundationTile {
+			t.Fatalf("no foundation under collector at %d,%d", tile[0], tile[1])
+		}
+	}
+}
+
+// TestDockCost checks the first dock bought costs dockCost, even in a world
+// without a starting dock, and that each one after doubles the cost
+func TestDockCost(t *testing.T) {
+	world := NewEmptyWorld(1)
+	if _, cost := world.DockCost(); cost != dockCost {
+		t.Fatalf("first dock of an empty world costs %d, want %d", cost, dockCost)
+	}
+
+	world = NewWorld(1)
+	if _, cost := world.DockCost(); cost != dockCost {
+		t.Fatalf("first dock costs %d, want %d", cost, dockCost)
+	}
+	world.Currencies[PlainBuck] = dockCost
+	if world.BuyDock(PlanDock(North, 10)) == nil {
+		t.Fatal("dock not bought")
+	}
+	if _, cost := world.DockCost(); cost != dockCost*2 {
+		t.Fatalf("second dock costs %d, want %d", cost, dockCost*2)
+	}
+}
@@ -0,0 +1,71 @@
+package sim
+
+// Position is a tile coordinate
+type Position struct {
+	X, Y int
+}
+
+// Grid indexes the IDs of objects or items by the tile they occupy.
+// IDs on a tile are kept in ascending order so lookups are deterministic.
+type Grid map[Position][]uint64
+
+// Add stores an id on the given tile
+func (g Grid) Add(x, y int, id uint64) {
+	position := Position{x, y}
+	ids := g[position]
+	index := len(ids)
+	for i, existing := range ids {
+		if existing == id {
+			return
+		}
+		if existing > id {
+			index = i
+			break
+		}
+	}
+	ids = append(ids, 0)
+	copy(ids[index+1:], ids[index:])
+	ids[index] = id
+	g[position] = ids
+}
+
+// Remove deletes an id from the given tile
+func (g Grid) Remove(x, y int, id uint64) {
+	position := Position{x, y}
+	ids := g[position]
+	for i, existing := range ids {
+		if existing == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(g, position)
+	} else {
+		g[position] = ids
+	}
+}
+
+// First returns the lowest id on the given tile.
+// Returns false if the tile is empty.
+func (g Grid) First(x, y int) (uint64, bool) {
+	ids := g[Position{x, y}]
+	if len(ids) == 0 {
+		return 0, false
+	}
+	return ids[0], true
+}
+
+// Reindex rebuilds the object and item grids from the World's maps.
+// Must be called after a World is loaded or its maps are edited directly.
+func (w *World) Reindex() {
+	w.objectGrid = Grid{}
+	for _, object := range w.Objects {
+		w.objectGrid.Add(object.X, object.Y, object.ID)
+	}
+
+	w.itemGrid = Grid{}
+	for _, item := range w.Items {
+		w.itemGrid.Add(item.TargetX, item.TargetY, item.ID)
+	}
+}
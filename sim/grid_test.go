@@ -0,0 +1,49 @@
+package sim
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	benchmarkLines      = 100 // Builders, each feeding a line of belts.
+	benchmarkLineLength = 50  // Belts in each line.
+)
+
+// BenchmarkTick5000Belts ticks a factory of 5,000 belts, in lines fed by a
+// builder and emptied by a collector. It runs at 60 TPS if a tick takes
+// less than 16.7ms, reported as ticks/s
+func BenchmarkTick5000Belts(b *testing.B) {
+	world := NewEmptyWorld(1)
+	for y := 0; y < benchmarkLines; y++ {
+		world.SpawnObject(Builder, 0, y, East)
+		for x := 1; x <= benchmarkLineLength; x++ {
+			world.SpawnObject(ConveyorBelt, x, y, East)
+		}
+		world.SpawnObject(Collector, benchmarkLineLength+1, y, East)
+	}
+	// fill the lines with dice before timing
+	for i := 0; i < TickRate*10; i++ {
+		world.Tick()
+	}
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		world.Tick()
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "ticks/s")
+}
+
+// TestMoveDeletedObject checks a deleted object isn't put back in the grid
+// by moving it
+func TestMoveDeletedObject(t *testing.T) {
+	world := NewEmptyWorld(1)
+	belt := world.SpawnObject(ConveyorBelt, 1, 1, East)
+	world.DeleteObject(belt)
+	world.MoveObject(belt, 2, 2)
+	if isObject, _ := world.GetObjectAt(2, 2); isObject {
+		t.Fatal("deleted object was moved into the grid")
+	}
+	world.Tick()
+}
@@ -0,0 +1,180 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+type ItemType int
+
+// Item types the code refers to. Other types are defined only in the data
+// files
+const (
+	PlainD6 ItemType = 0
+	GoldD6  ItemType = 1
+)
+
+func (i ItemType) String() string {
+	if !IsItemType(i) {
+		return fmt.Sprintf("ItemType(%d)", int(i))
+	}
+	return registry.item(i).Name
+}
+
+// Faces returns the number of faces on a die of this type
+func (i ItemType) Faces() int {
+	return registry.item(i).Faces
+}
+
+// Sprite returns the image file of the type's sprite sheet
+func (i ItemType) Sprite() string {
+	return registry.item(i).Sprite
+}
+
+// Currency returns the currency a die of this type sells for
+func (i ItemType) Currency() CurrencyType {
+	return registry.item(i).Currency
+}
+
+// MaxFaces returns the most faces on any type of die
+func MaxFaces() int {
+	max := 0
+	for _, item := range registry.Items {
+		if item.Faces > max {
+			max = item.Faces
+		}
+	}
+	return max
+}
+
+type Item struct {
+	Item               ItemType
+	Face               int          // value shown on face
+	Currency           CurrencyType // type of currency
+	X, Y               float64
+	ID                 uint64  // unique generated identifier
+	TargetX, TargetY   int     // index of target object
+	CatchupX, CatchupY float64 // if item is behind, saves lost distance
+}
+
+func (i *Item) Value() uint64 {
+	return uint64(i.Face) * registry.item(i.Item).Multiplier
+}
+
+// Roll sets the item's face using the given RNG
+func (i *Item) Roll(rng *RNG) {
+	i.Face = rng.Intn(i.Item.Faces()) + 1
+}
+
+// Step moves an item conveyorSpeed units per second towards target
+// Stores catchup when theres movement left, adds on next movement
+func (i *Item) Step(speed float64) {
+	xDelta := ToReal(i.TargetX) - i.X
+	if math.Abs(xDelta) < speed*TickDelta {
+		if i.X != ToReal(i.TargetX) {
+			i.CatchupX += speed*TickDelta - math.Abs(xDelta)
+		}
+		i.X = ToReal(i.TargetX)
+	} else {
+		if xDelta > 0 {
+			i.X += speed*TickDelta + i.CatchupX
+		} else {
+			i.X -= speed*TickDelta + i.CatchupX
+		}
+		i.CatchupX = 0
+	}
+
+	yDelta := ToReal(i.TargetY) - i.Y
+	if math.Abs(yDelta) < speed*TickDelta {
+		if i.Y != ToReal(i.TargetY) {
+			i.CatchupY += speed*TickDelta - math.Abs(yDelta)
+		}
+		i.Y = ToReal(i.TargetY)
+	} else {
+		if yDelta > 0 {
+			i.Y += speed*TickDelta + i.CatchupY
+		} else {
+			i.Y -= speed*TickDelta + i.CatchupY
+		}
+		i.CatchupY = 0
+	}
+}
+
+// UpdateObjects will iterate through each Item and switch,
+// depending on their type. Each Item type may have different functionality.
+func (w *World) UpdateItems() {
+	for _, id := range sortedIDs(w.Items) {
+		item := w.Items[id]
+		isObject, object := w.GetObjectAt(item.TargetX, item.TargetY)
+
+		// if there is no object to go to
+		if !isObject {
+			w.DeleteItem(item)
+			continue
+		}
+
+		// if the truck has driven away while loading
+		if object.Object.Behavior() == CollectorBehavior && !object.IsCollecting {
+			w.DeleteItem(item)
+			continue
+		}
+
+		// has item reached target position?
+		if item.X == ToReal(item.TargetX) &&
+			item.Y == ToReal(item.TargetY) {
+			continue
+		}
+		w.Items[item.ID].Step(conveyorSpeed)
+	}
+}
+
+// SpawnItem will create an instance of an Item in the set.
+// The Item's position and Target position will be set to that of the creator.
+func (w *World) SpawnItem(itemType ItemType, creator *Object) *Item {
+	x, y := creator.X, creator.Y
+
+	item := &Item{
+		X:       ToReal(x),
+		Y:       ToReal(y),
+		ID:      w.NextID(),
+		Item:    itemType,
+		TargetX: x,
+		TargetY: y,
+	}
+	item.Roll(w.RNG)
+
+	w.Items[item.ID] = item
+	w.itemGrid.Add(x, y, item.ID)
+	return item
+}
+
+// TargetItem sets the tile coordinates an item moves towards
+func (w *World) TargetItem(item *Item, x, y int) {
+	w.itemGrid.Remove(item.TargetX, item.TargetY, item.ID)
+	item.TargetX = x
+	item.TargetY = y
+	w.itemGrid.Add(x, y, item.ID)
+}
+
+// DeleteItem removes an item from the world
+func (w *World) DeleteItem(item *Item) {
+	w.itemGrid.Remove(item.TargetX, item.TargetY, item.ID)
+	delete(w.Items, item.ID)
+}
+
+func (w *World) SetItem(
+	item *Item, itemType ItemType, currencyType CurrencyType) {
+	item.Item = itemType
+	item.Currency = currencyType
+}
+
+// GetItemTargeting will find an Item targeting a given Object.
+// If several items target the Object, the one with the lowest ID is returned.
+// if an item is not found, it will return false and an Empty Object Reference.
+func (w *World) GetItemTargeting(object *Object) (bool, *Item) {
+	id, isItem := w.itemGrid.First(object.X, object.Y)
+	if !isItem {
+		return false, &Item{}
+	}
+	return true, w.Items[id]
+}
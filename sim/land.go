@@ -0,0 +1,113 @@
+package sim
+
+import "math"
+
+// ChunkSize is the width and height in tiles of a chunk of land, the
+// smallest area that can be bought
+const ChunkSize int = 8
+
+// The size of the map in chunks of land
+const (
+	LandChunksX int = StageSizeX / ChunkSize
+	LandChunksY int = StageSizeY / ChunkSize
+)
+
+const (
+	startingChunksX = 3    // Chunks along the x axis owned by a new factory.
+	startingChunksY = 2    // Chunks along the y axis owned by a new factory.
+	landCost        = 500  // PlainBucks for the first chunk bought.
+	landCostGrowth  = 1.25 // Each chunk bought multiplies the next's cost by this.
+)
+
+// ChunkAt returns the chunk of land containing a tile coordinate
+func ChunkAt(x, y int) (int, int) {
+	return int(math.Floor(float64(x) / float64(ChunkSize))),
+		int(math.Floor(float64(y) / float64(ChunkSize)))
+}
+
+// isChunk returns true if the chunk coordinate is on the map
+func isChunk(chunkX, chunkY int) bool {
+	return chunkX >= 0 && chunkX < LandChunksX &&
+		chunkY >= 0 && chunkY < LandChunksY
+}
+
+// ownStartingPlot gives the world the chunks a new factory starts with
+func (w *World) ownStartingPlot() {
+	for chunkY := 0; chunkY < startingChunksY; chunkY++ {
+		for chunkX := 0; chunkX < startingChunksX; chunkX++ {
+			w.Land[chunkY][chunkX] = true
+		}
+	}
+}
+
+// IsOwned returns true if the tile coordinate is on land the factory owns
+func (w *World) IsOwned(x, y int) bool {
+	if !IsInStage(x, y) {
+		return false
+	}
+	chunkX, chunkY := ChunkAt(x, y)
+	return w.Land[chunkY][chunkX]
+}
+
+// CanPlace returns true if an object can be built or moved to a tile: the
+// tile is owned, empty and its terrain can be built on. Terrain may still
+// need a foundation first
+func (w *World) CanPlace(x, y int) bool {
+	isObject, _ := w.GetObjectAt(x, y)
+	return !isObject && w.IsOwned(x, y) && !w.TileAt(x, y).IsBlocked()
+}
+
+// CanBuyLand returns true if a chunk is on the map, not yet owned and beside
+// a chunk that is
+func (w *World) CanBuyLand(chunkX, chunkY int) bool {
+	if !isChunk(chunkX, chunkY) || w.Land[chunkY][chunkX] {
+		return false
+	}
+	for _, offset := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		x, y := chunkX+offset[0], chunkY+offset[1]
+		if isChunk(x, y) && w.Land[y][x] {
+			return true
+		}
+	}
+	return false
+}
+
+// LandCost returns the cost of buying another chunk of land.
+// Each chunk bought beyond the starting plot raises the cost of the next
+func (w *World) LandCost() (CurrencyType, uint64) {
+	bought := -startingChunksX * startingChunksY
+	for _, row := range w.Land {
+		for _, owned := range row {
+			if owned {
+				bought++
+			}
+		}
+	}
+	if bought < 0 {
+		bought = 0
+	}
+	return PlainBuck, uint64(landCost * math.Pow(landCostGrowth, float64(bought)))
+}
+
+// BuyLand will attempt to Pay for a chunk of land beside the owned land and
+// own it if successful
+func (w *World) BuyLand(chunkX, chunkY int) bool {
+	if !w.CanBuyLand(chunkX, chunkY) || !w.Pay(w.LandCost()) {
+		return false
+	}
+	w.Land[chunkY][chunkX] = true
+	return true
+}
+
+// PurchasableLand returns the chunks that can be bought, row by row
+func (w *World) PurchasableLand() [][2]int {
+	chunks := [][2]int{}
+	for chunkY := 0; chunkY < LandChunksY; chunkY++ {
+		for chunkX := 0; chunkX < LandChunksX; chunkX++ {
+			if w.CanBuyLand(chunkX, chunkY) {
+				chunks = append(chunks, [2]int{chunkX, chunkY})
+			}
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,188 @@
+package sim
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	minDemand      = 0.2  // Lowest share of a die's value the market pays.
+	maxDemand      = 3.0  // Highest share of a die's value the market pays.
+	saleDemandDrop = 0.95 // Demand is multiplied by this on every sale.
+	demandRecovery = 0.02 // Share of the gap to normal demand recovered per second.
+	spikeSeconds   = 90   // Seconds between demand spikes.
+	spikeDemand    = 2.0  // Demand of a type during a spike.
+	historySeconds = 10   // Seconds between price history samples.
+	historyLength  = 30   // Samples kept in the price history.
+)
+
+// Market stores the demand for each type and face of die. A demand of 1 pays
+// a die's full value. Selling lowers demand, which recovers over time.
+type Market struct {
+	Demand  map[ItemType]map[int]float64 // missing entries are 1
+	History map[ItemType][]float64       // average price of each type, oldest first
+}
+
+// NewMarket constructs a Market with normal demand for every die
+func NewMarket() *Market {
+	return &Market{
+		Demand:  map[ItemType]map[int]float64{},
+		History: map[ItemType][]float64{},
+	}
+}
+
+// DemandFor returns the demand for a type and face of die
+func (m *Market) DemandFor(itemType ItemType, face int) float64 {
+	demand, exists := m.Demand[itemType][face]
+	if !exists {
+		return 1
+	}
+	return demand
+}
+
+func (m *Market) setDemand(itemType ItemType, face int, demand float64) {
+	demand = math.Max(minDemand, math.Min(maxDemand, demand))
+	if _, exists := m.Demand[itemType]; !exists {
+		m.Demand[itemType] = map[int]float64{}
+	}
+	m.Demand[itemType][face] = demand
+}
+
+// Price returns what the market currently pays for a die, at least 1
+func (m *Market) Price(itemType ItemType, face int) uint64 {
+	value := float64(uint64(face) * registry.item(itemType).Multiplier)
+	price := math.Round(value * m.DemandFor(itemType, face))
+	return uint64(math.Max(1, price))
+}
+
+// AveragePrice returns the mean price of every face of a type of die
+func (m *Market) AveragePrice(itemType ItemType) float64 {
+	sum := uint64(0)
+	for face := 1; face <= itemType.Faces(); face++ {
+		sum += m.Price(itemType, face)
+	}
+	return float64(sum) / float64(itemType.Faces())
+}
+
+// BaseAveragePrice returns the mean value of every face of a type of die,
+// as paid at normal demand
+func BaseAveragePrice(itemType ItemType) float64 {
+	multiplier := float64(registry.item(itemType).Multiplier)
+	return float64(itemType.Faces()+1) / 2 * multiplier
+}
+
+// recover moves every demand a step back towards normal
+func (m *Market) recover() {
+	for itemType, faces := range m.Demand {
+		for face, demand := range faces {
+			demand += (1 - demand) * demandRecovery
+			if math.Abs(1-demand) < 0.001 {
+				delete(faces, face)
+				continue
+			}
+			faces[face] = demand
+		}
+		if len(faces) == 0 {
+			delete(m.Demand, itemType)
+		}
+	}
+}
+
+// UpdateMarket recovers demand each second, spikes the demand of a random
+// type the factory makes every spikeSeconds and samples the price history
+// every historySeconds. Each warehouse sells a die to the market at the rate
+// of its type.
+func (w *World) UpdateMarket() {
+	if w.Ticks%uint64(TickRate) == 0 {
+		w.Market.recover()
+	}
+
+	if w.Ticks%uint64(TickRate*spikeSeconds) == 0 {
+		itemTypes := w.ProducibleTypes()
+		if len(itemTypes) > 0 {
+			itemType := itemTypes[w.RNG.Intn(len(itemTypes))]
+			for face := 1; face <= itemType.Faces(); face++ {
+				demand := w.Market.DemandFor(itemType, face)
+				w.Market.setDemand(itemType, face, math.Max(demand, spikeDemand))
+			}
+		}
+	}
+
+	if w.Ticks%uint64(TickRate*historySeconds) == 0 {
+		for _, itemType := range ItemTypes() {
+			history := append(w.Market.History[itemType],
+				w.Market.AveragePrice(itemType))
+			if len(history) > historyLength {
+				history = history[len(history)-historyLength:]
+			}
+			w.Market.History[itemType] = history
+		}
+	}
+
+	for _, warehouse := range w.SortedWarehouses() {
+		sellTicks := uint64(TickRate * warehouse.Warehouse.SellSeconds())
+		if w.Ticks%sellTicks == 0 {
+			w.SellToMarket(warehouse)
+		}
+	}
+}
+
+// Sell adds the market price of the die to the correct currency and lowers
+// the demand for it. Sell is often best used with RemoveDie
+func (w *World) Sell(itemType ItemType, face int) {
+	w.Currencies[itemType.Currency()] += w.Market.Price(itemType, face)
+	w.Market.setDemand(itemType, face,
+		w.Market.DemandFor(itemType, face)*saleDemandDrop)
+}
+
+// SellToMarket sells a die the sell policy allows from a warehouse to the
+// market. The highest priced die is sold if the policy asks for it,
+// otherwise a random one. The policy counts the dice held in every warehouse
+func (w *World) SellToMarket(warehouse *Warehouse) {
+	type die struct {
+		item ItemType
+		face int
+	}
+	stock := w.Stock()
+	dice := []die{}
+	for itemType, faces := range warehouse.Storage.Dice {
+		for face, count := range faces {
+			if count > 0 && w.isSellable(itemType, face, stock[itemType][face]) {
+				dice = append(dice, die{itemType, face})
+			}
+		}
+	}
+	if len(dice) == 0 {
+		return
+	}
+	sort.Slice(dice, func(i, j int) bool {
+		if dice[i].item != dice[j].item {
+			return dice[i].item < dice[j].item
+		}
+		return dice[i].face < dice[j].face
+	})
+
+	pick := dice[0]
+	if w.SellPolicy.HighestFirst {
+		for _, d := range dice[1:] {
+			if w.Market.Price(d.item, d.face) > w.Market.Price(pick.item, pick.face) {
+				pick = d
+			}
+		}
+	} else {
+		pick = dice[w.RNG.Intn(len(dice))]
+	}
+	if warehouse.Storage.RemoveDie(pick.item, pick.face) {
+		w.Sell(pick.item, pick.face)
+	}
+}
+
+// isReserved returns true if an open order wants the dice of a type and face
+func (w *World) isReserved(itemType ItemType, face int, held uint64) bool {
+	for _, order := range w.Orders {
+		if order.reserves(itemType, face, held) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package sim
+
+type MergeMode int
+
+const (
+	MergeRoundRobin    MergeMode = iota // Inputs take turns.
+	MergePriorityBack                   // The input behind goes first.
+	MergePriorityLeft                   // The input on the left goes first.
+	MergePriorityRight                  // The input on the right goes first.
+	mergeModeCount
+)
+
+func (m MergeMode) String() string {
+	switch m {
+	case MergeRoundRobin:
+		return "Round Robin"
+	case MergePriorityBack:
+		return "Back First"
+	case MergePriorityLeft:
+		return "Left First"
+	case MergePriorityRight:
+		return "Right First"
+	default:
+		return ""
+	}
+}
+
+// CycleMergeMode sets a Merger to the next MergeMode
+func (o *Object) CycleMergeMode() {
+	o.MergeMode = (o.MergeMode + 1) % mergeModeCount
+	o.MergeNext = 0
+}
+
+// mergeInputs returns the sides a Merger facing the given direction takes
+// items from, in the order back, left, right
+func mergeInputs(facing CardinalDir) [3]CardinalDir {
+	return [3]CardinalDir{facing.Opposite(), facing.Left(), facing.Right()}
+}
+
+// MergeItemInto pulls an item onto a Merger from a conveyor belt facing it.
+// In round-robin mode the inputs take turns, otherwise the priority input
+// is always checked first. Belts facing a Merger wait for it to pull from
+// them, so no items are dropped.
+func (w *World) MergeItemInto(object *Object) {
+	// is the merger still holding an item?
+	isItem, _ := w.GetItemTargeting(object)
+	if isItem {
+		return
+	}
+
+	first := object.MergeNext
+	if object.MergeMode != MergeRoundRobin {
+		first = int(object.MergeMode - MergePriorityBack)
+	}
+
+	inputs := mergeInputs(object.Facing)
+	for i := range inputs {
+		index := (first + i) % len(inputs)
+
+		// is there a belt facing the merger from this side?
+		isNeighbor, source := w.GetNeighborTowards(object, inputs[index])
+		if !isNeighbor ||
+			source.Object.Behavior() != BeltBehavior ||
+			source.Facing != inputs[index].Opposite() {
+			continue
+		}
+
+		isItemOn, item := w.IsItemOn(source)
+		if !isItemOn {
+			continue
+		}
+
+		w.TargetItem(item, object.X, object.Y)
+		if object.MergeMode == MergeRoundRobin {
+			object.MergeNext = (index + 1) % len(inputs)
+		}
+		return
+	}
+}
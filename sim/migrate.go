@@ -0,0 +1,574 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// Old migrations use the values of the version they upgrade to, not the
+// current game's, so old saves load the same however the game changes.
+const (
+	v0TileSize         = 64 // Pixels per tile of positions in every version so far.
+	v13DispatchSeconds = 10 // Wait of a new dispatch rule from version 13.
+)
+
+// A migration upgrades a decoded save document by a single version.
+type migration func(save map[string]any) error
+
+// migrations[v] upgrades a save from version v to version v+1
+var migrations = []migration{
+	migrateV0,
+	migrateV1,
+	migrateV2,
+	migrateV3,
+	migrateV4,
+	migrateV5,
+	migrateV6,
+	migrateV7,
+	migrateV8,
+	migrateV9,
+	migrateV10,
+	migrateV11,
+	migrateV12,
+	migrateV13,
+	migrateV14,
+	migrateV15,
+	migrateV16,
+	migrateV17,
+	migrateV18,
+	migrateV19,
+}
+
+// migrate upgrades a decoded save document to SaveVersion in place
+func migrate(save map[string]any) error {
+	version := 0
+	if value, exists := save["Version"]; exists {
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("malformed save: invalid version %v", value)
+		}
+		parsed, err := strconv.Atoi(number.String())
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("malformed save: invalid version %v", value)
+		}
+		version = parsed
+	}
+
+	if version > SaveVersion {
+		return fmt.Errorf("save version %d is newer than supported version %d",
+			version, SaveVersion)
+	}
+	for ; version < SaveVersion; version++ {
+		if err := migrations[version](save); err != nil {
+			return fmt.Errorf("migrating save from version %d: %w", version, err)
+		}
+		save["Version"] = version + 1
+	}
+	return nil
+}
+
+// migrateV0 upgrades the original save, which was the Game struct marshalled
+// directly, with maps keyed by ID and trucks holding copies of their storage
+// and collectors.
+func migrateV0(save map[string]any) error {
+	// the RNG is seeded from the save, so the same save always loads the same
+	contents, err := json.Marshal(save)
+	if err != nil {
+		return err
+	}
+	hash := fnv.New64a()
+	hash.Write(contents)
+	seed := int64(hash.Sum64())
+
+	objects, err := mapValues(save, "Objects")
+	if err != nil {
+		return err
+	}
+	save["Objects"] = objects
+
+	items, err := mapValues(save, "Items")
+	if err != nil {
+		return err
+	}
+	save["Items"] = items
+
+	storages, err := mapValues(save, "Storages")
+	if err != nil {
+		return err
+	}
+
+	warehouse, ok := save["Warehouse"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("missing Warehouse")
+	}
+	storages = append(storages, warehouse)
+	save["WarehouseID"] = warehouse["ID"]
+	delete(save, "Warehouse")
+
+	// trucks referenced their storage and collectors by value
+	trucks, err := mapValues(save, "Trucks")
+	if err != nil {
+		return err
+	}
+	for _, value := range trucks {
+		truck, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid truck %v", value)
+		}
+		storage, ok := truck["Storage"].(map[string]any)
+		if !ok {
+			return fmt.Errorf("truck %v is missing its storage", truck["ID"])
+		}
+		truck["StorageID"] = storage["ID"]
+		delete(truck, "Storage")
+
+		// the truck's copy is the one that was being loaded
+		for index, existing := range storages {
+			if existing.(map[string]any)["ID"] == storage["ID"] {
+				storages = append(storages[:index], storages[index+1:]...)
+				break
+			}
+		}
+		storages = append(storages, storage)
+
+		collectors, _ := truck["Collectors"].([]any)
+		collectorIDs := []any{}
+		for _, collector := range collectors {
+			object, ok := collector.(map[string]any)
+			if !ok {
+				return fmt.Errorf("truck %v has an invalid collector", truck["ID"])
+			}
+			collectorIDs = append(collectorIDs, object["ID"])
+		}
+		truck["CollectorIDs"] = collectorIDs
+		delete(truck, "Collectors")
+	}
+	save["Trucks"] = trucks
+	save["Storages"] = storages
+
+	// the hotbar was stored as UI objects rather than unlocked types
+	if _, exists := save["Unlocked"]; !exists {
+		uiObjects, _ := save["UIObjects"].([]any)
+		unlocked := []any{}
+		for _, value := range uiObjects {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("invalid UI object %v", value)
+			}
+			unlocked = append(unlocked, object["Object"])
+		}
+		if len(unlocked) == 0 {
+			unlocked = []any{ConveyorBelt, Builder}
+		}
+		save["Unlocked"] = unlocked
+	}
+	delete(save, "UIObjects")
+	delete(save, "ObjectCount")
+
+	if _, exists := save["RNG"]; !exists {
+		save["RNG"] = NewRNG(seed)
+	}
+
+	return nil
+}
+
+// migrateV1 adds the playtime, which was not tracked before version 2
+func migrateV1(save map[string]any) error {
+	save["Playtime"] = 0
+	return nil
+}
+
+// migrateV2 needs no changes. Version 3 added Splitters and their settings,
+// which older versions cannot load.
+func migrateV2(save map[string]any) error {
+	return nil
+}
+
+// migrateV3 needs no changes. Version 4 added Mergers and their settings,
+// which older versions cannot load.
+func migrateV3(save map[string]any) error {
+	return nil
+}
+
+// migrateV4 needs no changes. Version 5 added Sorters and their filters,
+// which older versions cannot load.
+func migrateV4(save map[string]any) error {
+	return nil
+}
+
+// migrateV5 needs no changes. Version 6 added Rerollers and their settings,
+// which older versions cannot load.
+func migrateV5(save map[string]any) error {
+	return nil
+}
+
+// migrateV6 needs no changes. Version 7 added the d4, d8, d10, d12 and d20
+// dice and their builders, which older versions cannot load.
+func migrateV6(save map[string]any) error {
+	return nil
+}
+
+// migrateV7 needs no changes. Version 8 added the Assembler and the recipe
+// and buffer it saves.
+func migrateV7(save map[string]any) error {
+	return nil
+}
+
+// migrateV8 adds an empty order board. Version 9 replaced random sales
+// with customer orders.
+func migrateV8(save map[string]any) error {
+	save["Orders"] = []any{}
+	return nil
+}
+
+// migrateV9 adds a market with normal demand. Version 10 added market
+// prices that change with sales.
+func migrateV9(save map[string]any) error {
+	save["Market"] = map[string]any{
+		"Demand":  map[string]any{},
+		"History": map[string]any{},
+	}
+	return nil
+}
+
+// migrateV10 adds a sell policy that sells any random die, as older
+// versions did. Version 11 added warehouse sell policies.
+func migrateV10(save map[string]any) error {
+	save["SellPolicy"] = map[string]any{
+		"HighestFirst": false,
+		"Reserve":      0,
+		"PriceFloor":   0,
+	}
+	return nil
+}
+
+// migrateV11 turns the single warehouse storage into a basic warehouse of
+// the same ID. Version 12 added multiple warehouses.
+func migrateV11(save map[string]any) error {
+	id, ok := save["WarehouseID"]
+	if !ok {
+		return fmt.Errorf("missing WarehouseID")
+	}
+	save["Warehouses"] = []any{map[string]any{
+		"ID":        id,
+		"Warehouse": BasicWarehouse,
+		"StorageID": id,
+		"Upgrades":  0,
+	}}
+	delete(save, "WarehouseID")
+	return nil
+}
+
+// migrateV12 gives every truck a manual dispatch rule, as older versions
+// only sent trucks when clicked. Version 13 added truck dispatch rules.
+func migrateV12(save map[string]any) error {
+	trucks, _ := save["Trucks"].([]any)
+	for _, value := range trucks {
+		truck, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid truck %v", value)
+		}
+		truck["Dispatch"] = map[string]any{
+			"Dispatch": ManualDispatch,
+			"Seconds":  v13DispatchSeconds,
+		}
+		truck["LoadingTicks"] = 0
+	}
+	return nil
+}
+
+// migrateV13 gives every truck a dock of its own, built from the collectors
+// and bay it was loaded at. Version 14 added truck fleets and docks.
+func migrateV13(save map[string]any) error {
+	lastID, err := jsonUint(save["ID"])
+	if err != nil {
+		return fmt.Errorf("invalid ID: %w", err)
+	}
+
+	trucks, _ := save["Trucks"].([]any)
+	docks := []any{}
+	for _, value := range trucks {
+		truck, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid truck %v", value)
+		}
+		tiles := map[string]int{}
+		for _, key := range []string{"SpawnX", "SpawnY", "TargetX", "TargetY"} {
+			position, err := jsonFloat(truck[key])
+			if err != nil {
+				return fmt.Errorf("truck %v has invalid %s: %w", truck["ID"], key, err)
+			}
+			tiles[key] = int(position) / v0TileSize
+		}
+
+		lastID++
+		docks = append(docks, map[string]any{
+			"ID":           lastID,
+			"CollectorIDs": truck["CollectorIDs"],
+			"SpawnX":       tiles["SpawnX"],
+			"SpawnY":       tiles["SpawnY"],
+			"TargetX":      tiles["TargetX"],
+			"TargetY":      tiles["TargetY"],
+			"Width":        truck["Width"],
+			"Height":       truck["Height"],
+			"Queue":        []any{truck["ID"]},
+		})
+		truck["DockID"] = lastID
+	}
+	save["Docks"] = docks
+	save["ID"] = lastID
+	return nil
+}
+
+// migrateV14 gives every truck a route of its own dock, delivering to any
+// warehouse. Version 15 added truck routes.
+func migrateV14(save map[string]any) error {
+	trucks, _ := save["Trucks"].([]any)
+	for _, value := range trucks {
+		truck, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid truck %v", value)
+		}
+		truck["Route"] = map[string]any{
+			"Stops":     []any{truck["DockID"]},
+			"Delivery":  DeliverAnywhere,
+			"DeliverTo": 0,
+		}
+		truck["Stop"] = 0
+	}
+	return nil
+}
+
+// migrateV15 gives every dock a manual dispatch rule. Trucks keep their
+// own rules, which override their dock's. Version 16 gave docks their own
+// dispatch rules.
+func migrateV15(save map[string]any) error {
+	docks, _ := save["Docks"].([]any)
+	for _, value := range docks {
+		dock, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid dock %v", value)
+		}
+		dock["Dispatch"] = map[string]any{
+			"Dispatch": ManualDispatch,
+			"Seconds":  v13DispatchSeconds,
+		}
+	}
+	return nil
+}
+
+// The size of the map before version 17, when it was one screen, and in
+// version 17
+const (
+	v16StageSizeX = 22
+	v16StageSizeY = 12
+	v17StageSizeX = 64
+	v17StageSizeY = 40
+)
+
+// migrateV16 pads the tile stage with grass to the larger map, and moves the
+// tiles trucks arrive from at docks on the east and south edges out to the
+// new edges. Version 17 made the map larger than the screen.
+func migrateV16(save map[string]any) error {
+	rows, _ := save["TileStage"].([]any)
+	for y := range rows {
+		row, ok := rows[y].([]any)
+		if !ok {
+			return fmt.Errorf("invalid tile stage row %v", rows[y])
+		}
+		for len(row) < v17StageSizeX {
+			row = append(row, BasicGrass)
+		}
+		rows[y] = row
+	}
+	for len(rows) < v17StageSizeY {
+		row := make([]any, v17StageSizeX)
+		for x := range row {
+			row[x] = BasicGrass
+		}
+		rows = append(rows, row)
+	}
+	save["TileStage"] = rows
+
+	shiftX, shiftY := v17StageSizeX-v16StageSizeX, v17StageSizeY-v16StageSizeY
+	docks, _ := save["Docks"].([]any)
+	for _, value := range docks {
+		dock, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid dock %v", value)
+		}
+		for _, axis := range []struct {
+			key         string
+			edge, shift int
+		}{{"SpawnX", v16StageSizeX, shiftX}, {"SpawnY", v16StageSizeY, shiftY}} {
+			spawn, err := jsonFloat(dock[axis.key])
+			if err != nil {
+				return fmt.Errorf("dock %v has invalid %s: %w",
+					dock["ID"], axis.key, err)
+			}
+			if int(spawn) >= axis.edge {
+				dock[axis.key] = int(spawn) + axis.shift
+			}
+		}
+	}
+
+	trucks, _ := save["Trucks"].([]any)
+	for _, value := range trucks {
+		truck, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid truck %v", value)
+		}
+		progress, err := jsonFloat(truck["PercentComplete"])
+		if err != nil {
+			return fmt.Errorf("truck %v has invalid PercentComplete: %w",
+				truck["ID"], err)
+		}
+		for _, axis := range []struct {
+			spawn, position string
+			edge, shift     int
+		}{
+			{"SpawnX", "X", v16StageSizeX, shiftX},
+			{"SpawnY", "Y", v16StageSizeY, shiftY},
+		} {
+			spawn, err := jsonFloat(truck[axis.spawn])
+			if err != nil {
+				return fmt.Errorf("truck %v has invalid %s: %w",
+					truck["ID"], axis.spawn, err)
+			}
+			if spawn < float64(axis.edge*v0TileSize) {
+				continue
+			}
+			truck[axis.spawn] = spawn + float64(axis.shift*v0TileSize)
+			// trucks off the map wait at the tile they arrive from
+			if progress == 0 {
+				truck[axis.position] = spawn + float64(axis.shift*v0TileSize)
+			}
+		}
+	}
+	return nil
+}
+
+// The land of version 18, when land ownership was added
+const (
+	v18ChunkSize       = 8
+	v18LandChunksX     = v17StageSizeX / v18ChunkSize
+	v18LandChunksY     = v17StageSizeY / v18ChunkSize
+	v18StartingChunksX = 3
+	v18StartingChunksY = 2
+)
+
+// migrateV17 gives old factories the starting plot of land, and any chunk
+// of land an object was built on. Version 18 added land ownership.
+func migrateV17(save map[string]any) error {
+	owned := [v18LandChunksY][v18LandChunksX]bool{}
+	for chunkY := 0; chunkY < v18StartingChunksY; chunkY++ {
+		for chunkX := 0; chunkX < v18StartingChunksX; chunkX++ {
+			owned[chunkY][chunkX] = true
+		}
+	}
+
+	objects, _ := save["Objects"].([]any)
+	for _, value := range objects {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid object %v", value)
+		}
+		x, errX := jsonFloat(object["X"])
+		y, errY := jsonFloat(object["Y"])
+		if errX != nil || errY != nil {
+			return fmt.Errorf("object %v has an invalid position", object["ID"])
+		}
+		if x < 0 || y < 0 {
+			continue
+		}
+		chunkX, chunkY := int(x)/v18ChunkSize, int(y)/v18ChunkSize
+		if chunkX < v18LandChunksX && chunkY < v18LandChunksY {
+			owned[chunkY][chunkX] = true
+		}
+	}
+
+	land := []any{}
+	for _, row := range owned {
+		chunks := []any{}
+		for _, isOwned := range row {
+			chunks = append(chunks, isOwned)
+		}
+		land = append(land, chunks)
+	}
+	save["Land"] = land
+	return nil
+}
+
+// migrateV18 needs no changes. Version 19 added water, rock, deposits and
+// foundations to the tile stage, which older versions cannot load.
+func migrateV18(save map[string]any) error {
+	return nil
+}
+
+// migrateV19 needs no changes. Version 20 let trucks override their dock's
+// dispatch rule, and trucks saved without a rule use their dock's.
+func migrateV19(save map[string]any) error {
+	return nil
+}
+
+// jsonUint returns the unsigned integer held by a decoded JSON number, or by
+// a number written by an earlier migration
+func jsonUint(value any) (uint64, error) {
+	switch number := value.(type) {
+	case json.Number:
+		return strconv.ParseUint(number.String(), 10, 64)
+	case uint64:
+		return number, nil
+	case int:
+		if number >= 0 {
+			return uint64(number), nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not an unsigned number", value)
+}
+
+// jsonFloat returns the float held by a decoded JSON number, or by a number
+// written by an earlier migration
+func jsonFloat(value any) (float64, error) {
+	switch number := value.(type) {
+	case json.Number:
+		return number.Float64()
+	case float64:
+		return number, nil
+	case int:
+		return float64(number), nil
+	case uint64:
+		return float64(number), nil
+	}
+	return 0, fmt.Errorf("%v is not a number", value)
+}
+
+// mapValues returns the values of a JSON object keyed by ID, ordered by ID
+func mapValues(save map[string]any, key string) ([]any, error) {
+	values := []any{}
+	if save[key] == nil {
+		return values, nil
+	}
+	entries, ok := save[key].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s is not keyed by ID", key)
+	}
+
+	ids := []string{}
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		values = append(values, entries[id])
+	}
+	return values, nil
+}
@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestMigrateLegacySave loads the save committed beside the game, which was
+// written before saves were versioned, through every migration
+func TestMigrateLegacySave(t *testing.T) {
+	data, err := os.ReadFile("../save.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	world, err := UnmarshalSave(data)
+	if err != nil {
+		t.Fatalf("loading save.json: %v", err)
+	}
+
+	saved, err := world.MarshalSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var save struct{ Version int }
+	if err := json.Unmarshal(saved, &save); err != nil {
+		t.Fatal(err)
+	}
+	if save.Version != SaveVersion {
+		t.Fatalf("got version %d, want %d", save.Version, SaveVersion)
+	}
+
+	loaded, err := UnmarshalSave(saved)
+	if err != nil {
+		t.Fatalf("reloading migrated save: %v", err)
+	}
+	resaved, err := loaded.MarshalSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(saved, resaved) {
+		t.Fatal("migrated save changed when saved again")
+	}
+}
+
+// TestMigrateLegacySaveIsRepeatable checks loading the same old save twice
+// gives the same world
+func TestMigrateLegacySaveIsRepeatable(t *testing.T) {
+	data, err := os.ReadFile("../save.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saves := [][]byte{}
+	for i := 0; i < 2; i++ {
+		world, err := UnmarshalSave(data)
+		if err != nil {
+			t.Fatalf("loading save.json: %v", err)
+		}
+		saved, err := world.MarshalSave()
+		if err != nil {
+			t.Fatal(err)
+		}
+		saves = append(saves, saved)
+	}
+	if !bytes.Equal(saves[0], saves[1]) {
+		t.Fatal("save.json loaded differently the second time")
+	}
+}
+
+// TestMigrateV15KeepsTruckRules checks every truck keeps its dispatch rule
+// when docks are given rules of their own, not only the truck at the front
+func TestMigrateV15KeepsTruckRules(t *testing.T) {
+	rules := []any{
+		map[string]any{"Dispatch": DispatchWhenFull, "Seconds": 10},
+		map[string]any{"Dispatch": DispatchAfter, "Seconds": 60},
+	}
+	save := map[string]any{
+		"Trucks": []any{
+			map[string]any{"ID": 1, "Dispatch": rules[0]},
+			map[string]any{"ID": 2, "Dispatch": rules[1]},
+		},
+		"Docks": []any{map[string]any{"ID": 3, "Queue": []any{1, 2}}},
+	}
+	if err := migrateV15(save); err != nil {
+		t.Fatal(err)
+	}
+	for i, value := range save["Trucks"].([]any) {
+		dispatch := value.(map[string]any)["Dispatch"]
+		if dispatch == nil || dispatch.(map[string]any)["Dispatch"] !=
+			rules[i].(map[string]any)["Dispatch"] {
+			t.Fatalf("truck %d lost its dispatch rule", i+1)
+		}
+	}
+	if _, exists := save["Docks"].([]any)[0].(map[string]any)["Dispatch"]; !exists {
+		t.Fatal("dock was not given a dispatch rule")
+	}
+}
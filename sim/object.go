@@ -0,0 +1,396 @@
+package sim
+
+import "fmt"
+
+const conveyorSpeed float64 = float64(TileSize) / 1.75 // pixels per second
+
+type ObjectType int
+
+// Object types the code refers to. Other types are defined only in the data
+// files
+const (
+	PlainObject  ObjectType = iota
+	ConveyorBelt            // Moves items onto facing neighbor.
+	Builder                 // Spawns a new item every build cycle and moves.
+	Collector               // Deletes items
+	Upgrader                // Upgrades items
+	Splitter                // Alternates items between its side outputs.
+	Merger                  // Takes items from its other sides in turn.
+	Sorter                  // Moves matching items forward, others right.
+	Reroller                // Rerolls items showing low faces.
+)
+
+func (o ObjectType) String() string {
+	if !IsObjectType(o) {
+		return fmt.Sprintf("ObjectType(%d)", int(o))
+	}
+	return registry.object(o).Name
+}
+
+// Behavior returns the behavior objects of this type run each tick
+func (o ObjectType) Behavior() Behavior {
+	return registry.object(o).Behavior
+}
+
+// Sprite returns the image file of the type
+func (o ObjectType) Sprite() string {
+	return registry.object(o).Sprite
+}
+
+// cycleTicks returns the ticks per build, upgrade or reroll of the type
+func (o ObjectType) cycleTicks() int {
+	return TickRate * registry.object(o).CycleSeconds
+}
+
+type CardinalDir int
+
+const (
+	South CardinalDir = iota
+	West
+	North
+	East
+)
+
+// Left returns the direction to the left of an object facing d
+func (d CardinalDir) Left() CardinalDir {
+	return (d + 3) % 4
+}
+
+// Right returns the direction to the right of an object facing d
+func (d CardinalDir) Right() CardinalDir {
+	return (d + 1) % 4
+}
+
+// Opposite returns the direction behind an object facing d
+func (d CardinalDir) Opposite() CardinalDir {
+	return (d + 2) % 4
+}
+
+type Object struct {
+	Object ObjectType
+	X      int         // tile coord
+	Y      int         // tile coord
+	ID     uint64      // unique generated identifier
+	Facing CardinalDir // default South
+
+	IsCollecting bool // is the object collecting
+
+	SplitRatio [2]int // items a Splitter sends left and right per cycle
+	SplitCount int    // items a Splitter has sent in the current cycle
+
+	MergeMode MergeMode // how a Merger picks between its inputs
+	MergeNext int       // input a round-robin Merger checks first
+
+	SortFaces map[int]bool      // faces a Sorter moves forward
+	SortItems map[ItemType]bool // item types a Sorter moves forward
+
+	RerollBelow int    // a Reroller rerolls faces below this value
+	RerollItem  uint64 // ID of the item a Reroller is holding
+	RerollTicks int    // ticks a Reroller has spent on the held item
+
+	Recipe         string           // name of the recipe an Assembler makes
+	AssembleBuffer map[ItemType]int // ingredients an Assembler holds
+	AssembleTicks  int              // ticks an Assembler has spent assembling
+}
+
+func (o *Object) Rotate() {
+	o.Facing = (o.Facing + 1) % 4
+}
+
+// IsItemOn tests if there is an item targeting the belt, and if it's currently
+// on the belt.
+// If so, it returns the item
+func (w *World) IsItemOn(object *Object) (bool, *Item) {
+	// is there an item targeting the belt?
+	isItem, item := w.GetItemTargeting(object)
+	if !isItem {
+		return false, item
+	}
+
+	// is the item currently on the belt?
+	if item.X != ToReal(object.X) ||
+		item.Y != ToReal(object.Y) {
+		return false, item
+	}
+
+	return true, item
+}
+
+// IsItemMoveable tests if the belt is pointing at an object and if theres an
+// item targeting the neighbor.
+// If so, it returns the neighbor
+func (w *World) IsItemMoveable(object *Object) (bool, *Object) {
+	return w.IsItemMoveableTowards(object, object.Facing)
+}
+
+// IsItemMoveableTowards tests if there is an object in the given direction
+// and if theres an item targeting the neighbor.
+// If so, it returns the neighbor
+func (w *World) IsItemMoveableTowards(
+	object *Object,
+	dir CardinalDir,
+) (bool, *Object) {
+	// is there an object in that direction?
+	isNeighbor, neighbor := w.GetNeighborTowards(object, dir)
+	if !isNeighbor {
+		return false, neighbor
+	}
+
+	// is there an item targeting the neighbor?
+	isItem, _ := w.GetItemTargeting(neighbor)
+	if isItem {
+		return false, neighbor
+	}
+
+	// mergers pull items from their inputs themselves
+	if neighbor.Object.Behavior() == MergerBehavior {
+		return false, neighbor
+	}
+
+	// is the item moving to or from a conveyor belt?
+	if object.Object.Behavior() != BeltBehavior &&
+		neighbor.Object.Behavior() != BeltBehavior {
+		return false, neighbor
+	}
+
+	return true, neighbor
+}
+
+// MoveItemOn tests IsItemOn and IsItemMoveable before setting an item's
+// target position to a neighbor. If the object is facing a collector,
+// it tests if the collector is full before moving
+func (w *World) MoveItemOn(object *Object) {
+	isItemOn, item := w.IsItemOn(object)
+	if !isItemOn {
+		return
+	}
+
+	isItemMoveable, neighbor := w.IsItemMoveable(object)
+	if !isItemMoveable {
+		return
+	}
+
+	w.MoveItemTo(item, neighbor)
+}
+
+// MoveItemTo sets an item's target position to a neighbor. If the neighbor
+// is a collector, the die is stored in its truck first.
+// Returns false if the collector is not ready or its truck is full
+func (w *World) MoveItemTo(item *Item, neighbor *Object) bool {
+	// is the item moving onto an unready collector?
+	if neighbor.Object.Behavior() == CollectorBehavior {
+		if !neighbor.IsCollecting {
+			return false
+		}
+		for _, truck := range w.Trucks {
+			// only the truck at the bay is loaded, not those queued behind it
+			if !w.IsLoading(truck) {
+				continue
+			}
+			for _, id := range truck.CollectorIDs {
+				if neighbor.ID != id {
+					continue
+				}
+				if !truck.Storage.StoreDie(item.Item, item.Face) {
+					return false
+				}
+			}
+		}
+	}
+
+	// set the item to target that object
+	w.TargetItem(item, neighbor.X, neighbor.Y)
+	return true
+}
+
+// UpdateObjects will iterate through each Object and switch,
+// depending on the behavior of their type. Each behavior may have different
+// functionality
+func (w *World) UpdateObjects() {
+	for _, id := range sortedIDs(w.Objects) {
+		object, exists := w.Objects[id]
+		if !exists {
+			continue
+		}
+		switch object.Object.Behavior() {
+		case BeltBehavior:
+			w.MoveItemOn(object)
+		case BuilderBehavior:
+			if w.Ticks%uint64(w.cycleTicks(object)) == 0 {
+				isItemMoveable, _ := w.IsItemMoveable(object)
+				if isItemMoveable {
+					w.SpawnItem(registry.object(object.Object).Builds, object)
+					w.Produced++
+				}
+			}
+			w.MoveItemOn(object)
+		case CollectorBehavior:
+			isItemOn, item := w.IsItemOn(object)
+			if isItemOn {
+				w.DeleteItem(item)
+			}
+		case UpgraderBehavior:
+			isItemOn, item := w.IsItemOn(object)
+			if isItemOn && w.Ticks%uint64(w.cycleTicks(object)) == 0 {
+				recipe, isRecipe := RecipeFor(object.Object, item.Item)
+				if isRecipe {
+					w.SetItem(item, recipe.Output, recipe.Output.Currency())
+				}
+				w.MoveItemOn(object)
+			}
+		case SplitterBehavior:
+			w.SplitItemOn(object)
+		case MergerBehavior:
+			w.MergeItemInto(object)
+			w.MoveItemOn(object)
+		case SorterBehavior:
+			w.SortItemOn(object)
+		case RerollerBehavior:
+			w.RerollItemOn(object)
+		case AssemblerBehavior:
+			w.AssembleItemOn(object)
+		}
+	}
+}
+
+// GetNeighborOf looks at the tile the object is facing to check for an Object
+// If there is an object, it returns true, and a reference to the Object
+// If there is no object, it returns false, and an empty Object
+func (w *World) GetNeighborOf(o *Object) (bool, *Object) {
+	return w.GetNeighborTowards(o, o.Facing)
+}
+
+// GetNeighborTowards looks at the adjacent tile in the given direction to
+// check for an Object
+// If there is an object, it returns true, and a reference to the Object
+// If there is no object, it returns false, and an empty Object
+func (w *World) GetNeighborTowards(o *Object, dir CardinalDir) (bool, *Object) {
+	switch dir {
+	case South:
+		isObject, object := w.GetObjectAt(o.X, o.Y+1)
+		if isObject {
+			return true, object
+		}
+	case West:
+		isObject, object := w.GetObjectAt(o.X-1, o.Y)
+		if isObject {
+			return true, object
+		}
+	case North:
+		isObject, object := w.GetObjectAt(o.X, o.Y-1)
+		if isObject {
+			return true, object
+		}
+	case East:
+		isObject, object := w.GetObjectAt(o.X+1, o.Y)
+		if isObject {
+			return true, object
+		}
+	}
+	return false, &Object{}
+}
+
+// GetObjectAt returns true if there is an Object at the given coordinates
+// The Object with the lowest ID at that coordinate is also returned.
+func (w *World) GetObjectAt(x, y int) (bool, *Object) {
+	id, isObject := w.objectGrid.First(x, y)
+	if !isObject {
+		return false, &Object{}
+	}
+	return true, w.Objects[id]
+}
+
+// SpawnObject constructs a new object of ObjectType
+func (w *World) SpawnObject(
+	objectType ObjectType,
+	x, y int,
+	facing CardinalDir,
+) *Object {
+	object := Object{
+		Object: objectType,
+		ID:     w.NextID(),
+		X:      x,
+		Y:      y,
+		Facing: facing,
+	}
+	switch objectType.Behavior() {
+	case SplitterBehavior:
+		object.SplitRatio = splitRatios[0]
+	case RerollerBehavior:
+		object.RerollBelow = defaultRerollBelow
+	case AssemblerBehavior:
+		object.AssembleBuffer = map[ItemType]int{}
+		recipes := RecipesFor(objectType)
+		if len(recipes) > 0 {
+			object.Recipe = recipes[0].Name
+		}
+	case SorterBehavior:
+		object.SortFaces = map[int]bool{}
+		object.SortItems = map[ItemType]bool{}
+		for _, itemType := range ItemTypes() {
+			object.SortItems[itemType] = true
+			for face := 1; face <= itemType.Faces(); face++ {
+				object.SortFaces[face] = true
+			}
+		}
+	}
+	w.ObjectCount[objectType] += 1
+	w.UnlockObject(objectType)
+
+	w.Objects[object.ID] = &object
+	w.objectGrid.Add(x, y, object.ID)
+	return &object
+}
+
+// MoveObject moves an object to the given tile coordinates. Objects that
+// have been deleted are not moved
+func (w *World) MoveObject(object *Object, x, y int) {
+	if _, exists := w.Objects[object.ID]; !exists {
+		return
+	}
+	w.objectGrid.Remove(object.X, object.Y, object.ID)
+	object.X = x
+	object.Y = y
+	w.objectGrid.Add(x, y, object.ID)
+}
+
+// DeleteObject removes an object from the world. The collectors of a dock
+// can't be deleted, as its trucks load through them.
+// Returns false if the object was not deleted
+func (w *World) DeleteObject(object *Object) bool {
+	if w.isDockCollector(object) {
+		return false
+	}
+	w.ObjectCount[object.Object] -= 1
+	w.objectGrid.Remove(object.X, object.Y, object.ID)
+	delete(w.Objects, object.ID)
+	return true
+}
+
+// UnlockObject makes buyable every object type unlocked by owning the
+// current count of the given type
+func (w *World) UnlockObject(objectType ObjectType) {
+	for _, def := range registry.Objects {
+		if def.Unlock != nil && def.Unlock.After == objectType &&
+			uint64(def.Unlock.Count) == w.ObjectCount[objectType] {
+			w.Unlock(def.ID)
+		}
+	}
+}
+
+// Unlock makes an object type buyable if it is not already
+func (w *World) Unlock(objectType ObjectType) {
+	if !w.IsUnlocked(objectType) {
+		w.Unlocked = append(w.Unlocked, objectType)
+	}
+}
+
+// IsUnlocked returns true if the object type can be bought
+func (w *World) IsUnlocked(objectType ObjectType) bool {
+	for _, unlocked := range w.Unlocked {
+		if unlocked == objectType {
+			return true
+		}
+	}
+	return false
+}
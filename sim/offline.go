@@ -0,0 +1,67 @@
+package sim
+
+import "time"
+
+// offlineSimBudget is the real time CatchUp may spend simulating. Any time
+// left over is skipped, so the world only changes as it was simulated.
+const offlineSimBudget = 2 * time.Second
+
+// OfflineSummary describes the progress made while the game was closed
+type OfflineSummary struct {
+	Elapsed  time.Duration           // time caught up, after the cap
+	Skipped  time.Duration           // time away that there was no time to simulate
+	Produced uint64                  // dice built by builders
+	Earned   map[CurrencyType]uint64 // currencies earned from orders, less penalties
+}
+
+// CatchUp advances the world by the time elapsed since it was saved, up to
+// maxElapsed. Ticks are simulated until offlineSimBudget runs out, and any
+// time left is skipped rather than estimated, so the dice, orders and trucks
+// match the currencies earned.
+func (w *World) CatchUp(elapsed, maxElapsed time.Duration) OfflineSummary {
+	return w.catchUp(elapsed, maxElapsed, offlineSimBudget)
+}
+
+func (w *World) catchUp(elapsed, maxElapsed, budget time.Duration) OfflineSummary {
+	if elapsed > maxElapsed {
+		elapsed = maxElapsed
+	}
+	summary := OfflineSummary{
+		Earned: map[CurrencyType]uint64{},
+	}
+	if elapsed <= 0 {
+		return summary
+	}
+
+	totalTicks := uint64(elapsed.Seconds() * float64(TickRate))
+	startProduced := w.Produced
+	startCurrencies := map[CurrencyType]uint64{}
+	for currency, value := range w.Currencies {
+		startCurrencies[currency] = value
+	}
+
+	// simulate as much as the budget allows
+	start := time.Now()
+	var ticks uint64
+	for ticks < totalTicks {
+		w.Tick()
+		ticks++
+		if ticks%uint64(TickRate) == 0 && time.Since(start) > budget {
+			break
+		}
+	}
+
+	summary.Elapsed = time.Duration(ticks) * time.Second / time.Duration(TickRate)
+	summary.Skipped = elapsed - summary.Elapsed
+	if summary.Skipped < 0 {
+		summary.Skipped = 0
+	}
+	summary.Produced = w.Produced - startProduced
+	for currency, value := range w.Currencies {
+		// penalties may leave less than was started with
+		if value > startCurrencies[currency] {
+			summary.Earned[currency] = value - startCurrencies[currency]
+		}
+	}
+	return summary
+}
@@ -0,0 +1,40 @@
+package sim
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCatchUpSkipsUnsimulatedTime checks time there is no budget to
+// simulate is skipped and reported, rather than estimated
+func TestCatchUpSkipsUnsimulatedTime(t *testing.T) {
+	world := NewWorld(1)
+	startTicks := world.Ticks
+	summary := world.catchUp(8*time.Hour, 8*time.Hour, 0)
+
+	simulated := time.Duration(world.Ticks-startTicks) * time.Second /
+		time.Duration(TickRate)
+	if summary.Elapsed != simulated {
+		t.Fatalf("caught up %s, but simulated %s", summary.Elapsed, simulated)
+	}
+	if summary.Elapsed+summary.Skipped != 8*time.Hour {
+		t.Fatalf("caught up %s and skipped %s, want 8h in all",
+			summary.Elapsed, summary.Skipped)
+	}
+}
+
+// TestCatchUpSimulatesShortAbsences checks a short absence is simulated in
+// full
+func TestCatchUpSimulatesShortAbsences(t *testing.T) {
+	world := NewWorld(1)
+	startProduced := world.Produced
+	summary := world.CatchUp(time.Minute, 8*time.Hour)
+	if summary.Elapsed != time.Minute || summary.Skipped != 0 {
+		t.Fatalf("caught up %s and skipped %s, want 1m and 0s",
+			summary.Elapsed, summary.Skipped)
+	}
+	if summary.Produced != world.Produced-startProduced {
+		t.Fatalf("reported %d dice produced, but %d were",
+			summary.Produced, world.Produced-startProduced)
+	}
+}
@@ -0,0 +1,243 @@
+package sim
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	maxOrders         = 3  // Most orders on the board at once.
+	orderSeconds      = 30 // Seconds between new orders.
+	orderPenaltyShare = 4  // Expired orders cost 1/orderPenaltyShare of the reward.
+)
+
+type OrderKind int
+
+const (
+	BulkOrder     OrderKind = iota // Count dice of a type showing any face.
+	FaceOrder                      // Count dice of a type showing Face.
+	StraightOrder                  // A die of a type showing each face.
+)
+
+// Order is a customer's request for dice from the warehouse
+type Order struct {
+	ID        uint64
+	Order     OrderKind
+	Item      ItemType
+	Face      int    // face wanted by a FaceOrder
+	Count     int    // dice wanted by a BulkOrder or FaceOrder
+	Reward    uint64 // paid when the order is filled
+	Penalty   uint64 // charged when the order expires
+	TicksLeft uint64 // ticks until the order expires
+}
+
+func (o *Order) String() string {
+	switch o.Order {
+	case BulkOrder:
+		return fmt.Sprintf("%d %s", o.Count, o.Item)
+	case FaceOrder:
+		return fmt.Sprintf("%d %s showing %d", o.Count, o.Item, o.Face)
+	case StraightOrder:
+		return fmt.Sprintf("a %s straight 1-%d", o.Item, o.Item.Faces())
+	}
+	return "unknown order"
+}
+
+// TimeLeft returns the time until the order expires
+func (o *Order) TimeLeft() time.Duration {
+	return time.Duration(o.TicksLeft) * time.Second / time.Duration(TickRate)
+}
+
+// wanted returns the number of dice per face an order takes from the stock,
+// or false if the stock cannot fill the order. Bulk orders take the lowest
+// faces first
+func (o *Order) wanted(stock map[ItemType]map[int]uint64) (map[int]uint64, bool) {
+	dice := stock[o.Item]
+	wanted := map[int]uint64{}
+	switch o.Order {
+	case BulkOrder:
+		remaining := uint64(o.Count)
+		for face := 1; face <= o.Item.Faces() && remaining > 0; face++ {
+			take := dice[face]
+			if take > remaining {
+				take = remaining
+			}
+			if take > 0 {
+				wanted[face] = take
+				remaining -= take
+			}
+		}
+		return wanted, remaining == 0
+	case FaceOrder:
+		wanted[o.Face] = uint64(o.Count)
+		return wanted, dice[o.Face] >= uint64(o.Count)
+	case StraightOrder:
+		for face := 1; face <= o.Item.Faces(); face++ {
+			if dice[face] < 1 {
+				return nil, false
+			}
+			wanted[face] = 1
+		}
+		return wanted, true
+	}
+	return nil, false
+}
+
+// reserves returns true if the order wants held dice of a type and face kept
+// in the warehouse. Straights keep one die of each face
+func (o *Order) reserves(itemType ItemType, face int, held uint64) bool {
+	if o.Item != itemType {
+		return false
+	}
+	switch o.Order {
+	case BulkOrder:
+		return true
+	case FaceOrder:
+		return face == o.Face
+	case StraightOrder:
+		return held <= 1
+	}
+	return false
+}
+
+// SortedOrders returns the orders on the board, oldest first
+func (w *World) SortedOrders() []*Order {
+	orders := []*Order{}
+	for _, id := range sortedIDs(w.Orders) {
+		orders = append(orders, w.Orders[id])
+	}
+	return orders
+}
+
+// UpdateOrders fills any order the warehouses have the dice for, and charges
+// the penalty of any order that runs out of time. A new order is added every
+// orderSeconds while the board has room.
+func (w *World) UpdateOrders() {
+	stock := w.Stock()
+	for _, id := range sortedIDs(w.Orders) {
+		order := w.Orders[id]
+		if w.FillOrder(order, stock) {
+			continue
+		}
+		if order.TicksLeft > 0 {
+			order.TicksLeft--
+			continue
+		}
+		currency := order.Item.Currency()
+		if w.Currencies[currency] > order.Penalty {
+			w.Currencies[currency] -= order.Penalty
+		} else {
+			w.Currencies[currency] = 0
+		}
+		delete(w.Orders, order.ID)
+	}
+
+	if w.Ticks%uint64(TickRate*orderSeconds) == 0 && len(w.Orders) < maxOrders {
+		w.NewOrder()
+	}
+}
+
+// FillOrder takes the dice an order wants from the warehouses and pays its
+// reward. Returns false if the warehouses do not hold the dice
+func (w *World) FillOrder(order *Order, stock map[ItemType]map[int]uint64) bool {
+	wanted, isFillable := order.wanted(stock)
+	if !isFillable {
+		return false
+	}
+	for face, count := range wanted {
+		for i := uint64(0); i < count; i++ {
+			w.RemoveStock(order.Item, face)
+			stock[order.Item][face]--
+		}
+	}
+	w.Currencies[order.Item.Currency()] += order.Reward
+	delete(w.Orders, order.ID)
+	return true
+}
+
+// DeliverOrder takes the dice an order wants from a storage, such as a
+// truck's, and pays its reward. Returns false if the storage does not hold
+// the dice
+func (w *World) DeliverOrder(order *Order, storage *Storage) bool {
+	wanted, isFillable := order.wanted(storage.Dice)
+	if !isFillable {
+		return false
+	}
+	for face, count := range wanted {
+		for i := uint64(0); i < count; i++ {
+			storage.RemoveDie(order.Item, face)
+		}
+	}
+	w.Currencies[order.Item.Currency()] += order.Reward
+	delete(w.Orders, order.ID)
+	return true
+}
+
+// ProducibleTypes lists the item types in the warehouses or made by an owned
+// object, in the order of ItemTypes
+func (w *World) ProducibleTypes() []ItemType {
+	isOrderable := map[ItemType]bool{}
+	for itemType := range w.Stock() {
+		isOrderable[itemType] = true
+	}
+	for _, def := range registry.Objects {
+		if w.ObjectCount[def.ID] == 0 {
+			continue
+		}
+		if def.Behavior == BuilderBehavior {
+			isOrderable[def.Builds] = true
+		}
+		for _, recipe := range RecipesFor(def.ID) {
+			isOrderable[recipe.Output] = true
+		}
+	}
+
+	itemTypes := []ItemType{}
+	for _, itemType := range ItemTypes() {
+		if isOrderable[itemType] {
+			itemTypes = append(itemTypes, itemType)
+		}
+	}
+	return itemTypes
+}
+
+// NewOrder adds a random order for dice the factory can make to the board.
+// Rewards pay more than the dice are worth, and more again for orders that
+// are harder to fill
+func (w *World) NewOrder() *Order {
+	itemTypes := w.ProducibleTypes()
+	if len(itemTypes) == 0 {
+		return nil
+	}
+	itemType := itemTypes[w.RNG.Intn(len(itemTypes))]
+	faces := itemType.Faces()
+	multiplier := registry.item(itemType).Multiplier
+
+	order := &Order{ID: w.NextID(), Item: itemType}
+	kind := OrderKind(w.RNG.Intn(3))
+	if faces == 1 {
+		kind = BulkOrder
+	}
+	var seconds int
+	switch kind {
+	case BulkOrder:
+		order.Count = 5 + w.RNG.Intn(16)
+		averageFace := uint64(faces+2) / 2
+		order.Reward = uint64(order.Count) * averageFace * multiplier * 3 / 2
+		seconds = 60 + 10*order.Count
+	case FaceOrder:
+		order.Face = 1 + w.RNG.Intn(faces)
+		order.Count = 2 + w.RNG.Intn(5)
+		order.Reward = uint64(order.Count*order.Face) * multiplier * 2
+		seconds = 60 + 15*order.Count*faces/6
+	case StraightOrder:
+		order.Reward = uint64(faces*(faces+1)/2) * multiplier * 3
+		seconds = 120 + 30*faces
+	}
+	order.Order = kind
+	order.Penalty = order.Reward / orderPenaltyShare
+	order.TicksLeft = uint64(seconds * TickRate)
+
+	w.Orders[order.ID] = order
+	return order
+}
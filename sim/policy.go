@@ -0,0 +1,77 @@
+package sim
+
+// reserveSteps are the numbers of each face a SellPolicy can keep
+var reserveSteps = []int{0, 1, 5, 10, 25}
+
+// floorSteps are the price floors a SellPolicy can be set to, as a
+// percentage of a die's value
+var floorSteps = []int{0, 50, 75, 100, 125}
+
+// SellPolicy decides which warehouse dice are sold to the market
+type SellPolicy struct {
+	HighestFirst bool              // sell the highest priced die, not a random one
+	HoldItems    map[ItemType]bool // types that are never sold to the market
+	Reserve      int               // dice of each type and face kept unsold
+	PriceFloor   int               // lowest price sold at, as a percentage of value
+}
+
+// NewSellPolicy constructs a SellPolicy that sells any random die
+func NewSellPolicy() *SellPolicy {
+	return &SellPolicy{HoldItems: map[ItemType]bool{}}
+}
+
+// ToggleHold adds or removes a type from the types that are never sold
+func (p *SellPolicy) ToggleHold(itemType ItemType) {
+	if p.HoldItems == nil {
+		p.HoldItems = map[ItemType]bool{}
+	}
+	if p.HoldItems[itemType] {
+		delete(p.HoldItems, itemType)
+	} else {
+		p.HoldItems[itemType] = true
+	}
+}
+
+// CycleReserve sets the policy to keep the next number in reserveSteps
+func (p *SellPolicy) CycleReserve() {
+	p.Reserve = nextStep(reserveSteps, p.Reserve)
+}
+
+// CyclePriceFloor sets the policy to the next price floor in floorSteps
+func (p *SellPolicy) CyclePriceFloor() {
+	p.PriceFloor = nextStep(floorSteps, p.PriceFloor)
+}
+
+// nextStep returns the step after current, wrapping to the first
+func nextStep(steps []int, current int) int {
+	for index, step := range steps {
+		if step == current {
+			return steps[(index+1)%len(steps)]
+		}
+	}
+	return steps[0]
+}
+
+// isSellable returns true if the policy and open orders allow a die to be
+// sold to the market, given the number held
+func (w *World) isSellable(itemType ItemType, face int, held uint64) bool {
+	policy := w.SellPolicy
+	if held <= uint64(policy.Reserve) || policy.HoldItems[itemType] {
+		return false
+	}
+	value := uint64(face) * registry.item(itemType).Multiplier
+	if w.Market.Price(itemType, face)*100 < value*uint64(policy.PriceFloor) {
+		return false
+	}
+	return !w.isReserved(itemType, face, held)
+}
+
+// SellNow sells a die from the warehouses to the market, regardless of the
+// sell policy. Returns false if no warehouse holds such a die
+func (w *World) SellNow(itemType ItemType, face int) bool {
+	if !w.RemoveStock(itemType, face) {
+		return false
+	}
+	w.Sell(itemType, face)
+	return true
+}
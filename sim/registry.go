@@ -0,0 +1,883 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+
+	"github.com/Rolls71/dice-factory/data"
+)
+
+const (
+	itemsFile      = "items.json"
+	objectsFile    = "objects.json"
+	recipesFile    = "recipes.json"
+	warehousesFile = "warehouses.json"
+	trucksFile     = "trucks.json"
+	tilesFile      = "tiles.json"
+)
+
+// Behavior names the code that runs an object type each tick
+type Behavior string
+
+const (
+	NoBehavior        Behavior = "none"
+	BeltBehavior      Behavior = "belt"
+	BuilderBehavior   Behavior = "builder"
+	CollectorBehavior Behavior = "collector"
+	UpgraderBehavior  Behavior = "upgrader"
+	SplitterBehavior  Behavior = "splitter"
+	MergerBehavior    Behavior = "merger"
+	SorterBehavior    Behavior = "sorter"
+	RerollerBehavior  Behavior = "reroller"
+	AssemblerBehavior Behavior = "assembler"
+)
+
+var behaviors = map[Behavior]bool{
+	NoBehavior:        true,
+	BeltBehavior:      true,
+	BuilderBehavior:   true,
+	CollectorBehavior: true,
+	UpgraderBehavior:  true,
+	SplitterBehavior:  true,
+	MergerBehavior:    true,
+	SorterBehavior:    true,
+	RerollerBehavior:  true,
+	AssemblerBehavior: true,
+}
+
+// cycleBehaviors need an object type to set CycleSeconds
+var cycleBehaviors = map[Behavior]bool{
+	BuilderBehavior:   true,
+	UpgraderBehavior:  true,
+	RerollerBehavior:  true,
+	AssemblerBehavior: true,
+}
+
+var currencyNames = map[string]CurrencyType{
+	"PlainBuck": PlainBuck,
+	"GoldBuck":  GoldBuck,
+}
+
+// builtinObjects are object types the code refers to by name, and the
+// behavior each must have
+var builtinObjects = map[ObjectType]Behavior{
+	PlainObject:  NoBehavior,
+	ConveyorBelt: BeltBehavior,
+	Builder:      BuilderBehavior,
+	Collector:    CollectorBehavior,
+	Upgrader:     UpgraderBehavior,
+	Splitter:     SplitterBehavior,
+	Merger:       MergerBehavior,
+	Sorter:       SorterBehavior,
+	Reroller:     RerollerBehavior,
+}
+
+// builtinItems are item types the code refers to by name
+var builtinItems = []ItemType{PlainD6, GoldD6}
+
+// builtinWarehouses are warehouse types the code refers to by name
+var builtinWarehouses = []WarehouseType{BasicWarehouse}
+
+// builtinTrucks are truck types the code refers to by name
+var builtinTrucks = []TruckType{BasicTruck}
+
+// builtinTiles are tile types the code refers to by name
+var builtinTiles = []TileType{BasicGrass, LongGrass}
+
+// ItemDef describes a type of die
+type ItemDef struct {
+	ID         ItemType
+	Name       string
+	Faces      int          // faces numbered 1 to Faces
+	Sprite     string       // sprite sheet with a frame per face, in order
+	Currency   CurrencyType // currency the die sells for
+	Multiplier uint64       // value of the die per pip
+}
+
+// ObjectDef describes a type of object
+type ObjectDef struct {
+	ID           ObjectType
+	Name         string
+	Behavior     Behavior
+	Sprite       string
+	Builds       ItemType // item type a builder spawns
+	CycleSeconds int      // seconds per build, upgrade, reroll or assembly
+	Cost         *CostDef // nil if the object cannot be bought
+	Unlocked     bool     // is the object buyable in a new world
+	Unlock       *UnlockDef
+}
+
+// CostDef prices the next object of a type as
+// Scale * Base^(n+1) * (n+1)^Power, where n is the number already owned
+type CostDef struct {
+	Currency CurrencyType
+	Scale    float64
+	Base     float64
+	Power    float64
+}
+
+// UnlockDef makes an object buyable once Count of the After type are owned
+type UnlockDef struct {
+	After ObjectType
+	Count int
+}
+
+// Recipe turns Inputs into an Output item in a Machine
+type Recipe struct {
+	Name    string
+	Machine ObjectType
+	Inputs  []Ingredient
+	Output  ItemType
+}
+
+// Ingredient is a number of items of a type used by a Recipe
+type Ingredient struct {
+	Item  ItemType
+	Count int
+}
+
+// WarehouseDef describes a type of warehouse
+type WarehouseDef struct {
+	ID          WarehouseType
+	Name        string
+	Capacity    uint64 // dice held before any upgrades
+	SellSeconds int    // seconds per market sale
+	Cost        uint64 // PlainBucks for the first warehouse of the type
+}
+
+// TruckDef describes a type of truck
+type TruckDef struct {
+	ID             TruckType
+	Name           string
+	Sprite         string
+	Capacity       uint64  // dice held
+	TypeLimit      int     // types of dice held, 0 for infinite
+	ArrivalSeconds float64 // seconds to arrive at or leave a dock
+	Upkeep         uint64  // PlainBucks charged every upkeepSeconds
+	Cost           uint64  // PlainBucks for the first truck of the type
+}
+
+// TileDef describes a type of terrain
+type TileDef struct {
+	ID         TileType
+	Name       string
+	Sprite     string
+	Blocked    bool           // nothing can be built on it
+	Foundation *FoundationDef // nil if nothing is needed before building
+	Boost      *BoostDef      // nil if no machines work faster on it
+	Patches    *PatchDef      // nil if it is not scattered over new maps
+}
+
+// FoundationDef is built on a tile before anything else, turning it into
+// the Becomes type
+type FoundationDef struct {
+	Cost    uint64 // PlainBucks for the foundation
+	Becomes TileType
+}
+
+// BoostDef makes machines of a Behavior work Speed times faster
+type BoostDef struct {
+	Behavior Behavior
+	Speed    int
+}
+
+// PatchDef scatters Count patches of a terrain over a new map
+type PatchDef struct {
+	Count  int
+	Radius int // tiles from the centre of a patch to its edge
+}
+
+// Registry stores every object, item, recipe, warehouse, truck and tile
+// definition
+type Registry struct {
+	Items      []*ItemDef // in the order they are listed
+	Objects    []*ObjectDef
+	Recipes    []*Recipe
+	Warehouses []*WarehouseDef
+	Trucks     []*TruckDef
+	Tiles      []*TileDef
+
+	items      map[ItemType]*ItemDef
+	objects    map[ObjectType]*ObjectDef
+	warehouses map[WarehouseType]*WarehouseDef
+	trucks     map[TruckType]*TruckDef
+	tiles      map[TileType]*TileDef
+}
+
+// itemFile, objectFile, tileFile and recipeFile are the definitions as
+// written in the data files, which refer to currencies, items, objects and
+// tiles by name
+type itemFile struct {
+	ID         ItemType
+	Name       string
+	Faces      int
+	Sprite     string
+	Currency   string
+	Multiplier uint64
+}
+
+type objectFile struct {
+	ID           ObjectType
+	Name         string
+	Behavior     Behavior
+	Sprite       string
+	Builds       string
+	CycleSeconds int
+	Cost         *struct {
+		Currency string
+		Scale    float64
+		Base     float64
+		Power    float64
+	}
+	Unlocked bool
+	Unlock   *struct {
+		After string
+		Count int
+	}
+}
+
+type tileFile struct {
+	ID         TileType
+	Name       string
+	Sprite     string
+	Blocked    bool
+	Foundation *struct {
+		Cost    uint64
+		Becomes string
+	}
+	Boost   *BoostDef
+	Patches *PatchDef
+}
+
+type recipeFile struct {
+	Name    string
+	Machine string
+	Inputs  []struct {
+		Item  string
+		Count int
+	}
+	Output string
+}
+
+// registry is the Registry the simulation queries
+var registry *Registry
+
+func init() {
+	defaults, err := ParseRegistry(data.FS)
+	if err != nil {
+		log.Fatal(err)
+	}
+	registry = defaults
+}
+
+// LoadRegistry replaces the simulation's definitions with those in the given
+// directory. The definitions are left unchanged if any are invalid
+func LoadRegistry(fsys fs.FS) error {
+	loaded, err := ParseRegistry(fsys)
+	if err != nil {
+		return err
+	}
+	registry = loaded
+	return nil
+}
+
+// ParseRegistry reads and validates the definition files in a directory
+func ParseRegistry(fsys fs.FS) (*Registry, error) {
+	var items []itemFile
+	var objects []objectFile
+	var recipes []recipeFile
+	var warehouses []*WarehouseDef
+	var trucks []*TruckDef
+	var tiles []tileFile
+	if err := readDataFile(fsys, itemsFile, &items); err != nil {
+		return nil, err
+	}
+	if err := readDataFile(fsys, objectsFile, &objects); err != nil {
+		return nil, err
+	}
+	if err := readDataFile(fsys, recipesFile, &recipes); err != nil {
+		return nil, err
+	}
+	if err := readDataFile(fsys, warehousesFile, &warehouses); err != nil {
+		return nil, err
+	}
+	if err := readDataFile(fsys, trucksFile, &trucks); err != nil {
+		return nil, err
+	}
+	if err := readDataFile(fsys, tilesFile, &tiles); err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		items:      map[ItemType]*ItemDef{},
+		objects:    map[ObjectType]*ObjectDef{},
+		warehouses: map[WarehouseType]*WarehouseDef{},
+		trucks:     map[TruckType]*TruckDef{},
+		tiles:      map[TileType]*TileDef{},
+	}
+	if err := r.addItems(items); err != nil {
+		return nil, fmt.Errorf("%s: %w", itemsFile, err)
+	}
+	if err := r.addObjects(objects); err != nil {
+		return nil, fmt.Errorf("%s: %w", objectsFile, err)
+	}
+	if err := r.addRecipes(recipes); err != nil {
+		return nil, fmt.Errorf("%s: %w", recipesFile, err)
+	}
+	if err := r.addWarehouses(warehouses); err != nil {
+		return nil, fmt.Errorf("%s: %w", warehousesFile, err)
+	}
+	if err := r.addTrucks(trucks); err != nil {
+		return nil, fmt.Errorf("%s: %w", trucksFile, err)
+	}
+	if err := r.addTiles(tiles); err != nil {
+		return nil, fmt.Errorf("%s: %w", tilesFile, err)
+	}
+	return r, nil
+}
+
+func readDataFile(fsys fs.FS, name string, v any) error {
+	bytes, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bytes, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *Registry) addItems(items []itemFile) error {
+	names := map[string]bool{}
+	for _, item := range items {
+		if item.ID < 0 {
+			return fmt.Errorf("item %q has a negative ID", item.Name)
+		}
+		if _, exists := r.items[item.ID]; exists {
+			return fmt.Errorf("item ID %d is used twice", item.ID)
+		}
+		if item.Name == "" || names[item.Name] {
+			return fmt.Errorf("item %d needs a unique name", item.ID)
+		}
+		if item.Faces < 1 {
+			return fmt.Errorf("item %q needs at least one face", item.Name)
+		}
+		if item.Sprite == "" {
+			return fmt.Errorf("item %q has no sprite", item.Name)
+		}
+		currency, exists := currencyNames[item.Currency]
+		if !exists {
+			return fmt.Errorf("item %q has unknown currency %q",
+				item.Name, item.Currency)
+		}
+		if item.Multiplier < 1 {
+			return fmt.Errorf("item %q needs a multiplier of at least 1",
+				item.Name)
+		}
+
+		def := &ItemDef{
+			ID:         item.ID,
+			Name:       item.Name,
+			Faces:      item.Faces,
+			Sprite:     item.Sprite,
+			Currency:   currency,
+			Multiplier: item.Multiplier,
+		}
+		names[item.Name] = true
+		r.Items = append(r.Items, def)
+		r.items[item.ID] = def
+	}
+
+	for _, itemType := range builtinItems {
+		if _, exists := r.items[itemType]; !exists {
+			return fmt.Errorf("item %d is missing", itemType)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) addObjects(objects []objectFile) error {
+	names := map[string]bool{}
+	for _, object := range objects {
+		if object.ID < 0 {
+			return fmt.Errorf("object %q has a negative ID", object.Name)
+		}
+		if _, exists := r.objects[object.ID]; exists {
+			return fmt.Errorf("object ID %d is used twice", object.ID)
+		}
+		if object.Name == "" || names[object.Name] {
+			return fmt.Errorf("object %d needs a unique name", object.ID)
+		}
+		if !behaviors[object.Behavior] {
+			return fmt.Errorf("object %q has unknown behavior %q",
+				object.Name, object.Behavior)
+		}
+		if object.Sprite == "" {
+			return fmt.Errorf("object %q has no sprite", object.Name)
+		}
+		if cycleBehaviors[object.Behavior] && object.CycleSeconds < 1 {
+			return fmt.Errorf("object %q needs CycleSeconds", object.Name)
+		}
+
+		def := &ObjectDef{
+			ID:           object.ID,
+			Name:         object.Name,
+			Behavior:     object.Behavior,
+			Sprite:       object.Sprite,
+			CycleSeconds: object.CycleSeconds,
+			Unlocked:     object.Unlocked,
+		}
+		if object.Behavior == BuilderBehavior {
+			builds, exists := r.itemNamed(object.Builds)
+			if !exists {
+				return fmt.Errorf("object %q builds unknown item %q",
+					object.Name, object.Builds)
+			}
+			def.Builds = builds
+		}
+		if object.Cost != nil {
+			currency, exists := currencyNames[object.Cost.Currency]
+			if !exists {
+				return fmt.Errorf("object %q costs unknown currency %q",
+					object.Name, object.Cost.Currency)
+			}
+			if object.Cost.Scale <= 0 || object.Cost.Base <= 0 {
+				return fmt.Errorf("object %q needs a positive cost scale and base",
+					object.Name)
+			}
+			def.Cost = &CostDef{
+				Currency: currency,
+				Scale:    object.Cost.Scale,
+				Base:     object.Cost.Base,
+				Power:    object.Cost.Power,
+			}
+		}
+
+		names[object.Name] = true
+		r.Objects = append(r.Objects, def)
+		r.objects[object.ID] = def
+	}
+
+	// unlocks may refer to objects listed after them
+	for _, object := range objects {
+		if object.Unlock == nil {
+			continue
+		}
+		after, exists := r.objectNamed(object.Unlock.After)
+		if !exists {
+			return fmt.Errorf("object %q unlocks after unknown object %q",
+				object.Name, object.Unlock.After)
+		}
+		if object.Unlock.Count < 1 {
+			return fmt.Errorf("object %q needs an unlock count", object.Name)
+		}
+		r.objects[object.ID].Unlock = &UnlockDef{
+			After: after,
+			Count: object.Unlock.Count,
+		}
+	}
+
+	for objectType, behavior := range builtinObjects {
+		def, exists := r.objects[objectType]
+		if !exists || def.Behavior != behavior {
+			return fmt.Errorf("object %d must exist with behavior %q",
+				objectType, behavior)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) addRecipes(recipes []recipeFile) error {
+	names := map[string]bool{}
+	for _, recipe := range recipes {
+		if recipe.Name == "" || names[recipe.Name] {
+			return fmt.Errorf("recipe %q needs a unique name", recipe.Name)
+		}
+		names[recipe.Name] = true
+
+		machine, exists := r.objectNamed(recipe.Machine)
+		if !exists {
+			return fmt.Errorf("recipe %q uses unknown machine %q",
+				recipe.Name, recipe.Machine)
+		}
+		output, exists := r.itemNamed(recipe.Output)
+		if !exists {
+			return fmt.Errorf("recipe %q makes unknown item %q",
+				recipe.Name, recipe.Output)
+		}
+		if len(recipe.Inputs) == 0 {
+			return fmt.Errorf("recipe %q has no inputs", recipe.Name)
+		}
+
+		def := &Recipe{Name: recipe.Name, Machine: machine, Output: output}
+		for _, input := range recipe.Inputs {
+			item, exists := r.itemNamed(input.Item)
+			if !exists {
+				return fmt.Errorf("recipe %q uses unknown item %q",
+					recipe.Name, input.Item)
+			}
+			if input.Count < 1 {
+				return fmt.Errorf("recipe %q needs a count of %q",
+					recipe.Name, input.Item)
+			}
+			def.Inputs = append(def.Inputs, Ingredient{item, input.Count})
+		}
+		r.Recipes = append(r.Recipes, def)
+	}
+	return nil
+}
+
+func (r *Registry) addWarehouses(warehouses []*WarehouseDef) error {
+	names := map[string]bool{}
+	for _, warehouse := range warehouses {
+		if warehouse.ID < 0 {
+			return fmt.Errorf("warehouse %q has a negative ID", warehouse.Name)
+		}
+		if _, exists := r.warehouses[warehouse.ID]; exists {
+			return fmt.Errorf("warehouse ID %d is used twice", warehouse.ID)
+		}
+		if warehouse.Name == "" || names[warehouse.Name] {
+			return fmt.Errorf("warehouse %d needs a unique name", warehouse.ID)
+		}
+		if warehouse.Capacity < 1 {
+			return fmt.Errorf("warehouse %q needs a capacity of at least 1",
+				warehouse.Name)
+		}
+		if warehouse.SellSeconds < 1 {
+			return fmt.Errorf("warehouse %q needs SellSeconds", warehouse.Name)
+		}
+		if warehouse.Cost < 1 {
+			return fmt.Errorf("warehouse %q needs a cost of at least 1",
+				warehouse.Name)
+		}
+
+		names[warehouse.Name] = true
+		r.Warehouses = append(r.Warehouses, warehouse)
+		r.warehouses[warehouse.ID] = warehouse
+	}
+
+	for _, warehouseType := range builtinWarehouses {
+		if _, exists := r.warehouses[warehouseType]; !exists {
+			return fmt.Errorf("warehouse %d is missing", warehouseType)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) addTrucks(trucks []*TruckDef) error {
+	names := map[string]bool{}
+	for _, truck := range trucks {
+		if truck.ID < 0 {
+			return fmt.Errorf("truck %q has a negative ID", truck.Name)
+		}
+		if _, exists := r.trucks[truck.ID]; exists {
+			return fmt.Errorf("truck ID %d is used twice", truck.ID)
+		}
+		if truck.Name == "" || names[truck.Name] {
+			return fmt.Errorf("truck %d needs a unique name", truck.ID)
+		}
+		if truck.Sprite == "" {
+			return fmt.Errorf("truck %q has no sprite", truck.Name)
+		}
+		if truck.Capacity < 1 {
+			return fmt.Errorf("truck %q needs a capacity of at least 1",
+				truck.Name)
+		}
+		if truck.TypeLimit < 0 {
+			return fmt.Errorf("truck %q has a negative type limit", truck.Name)
+		}
+		if truck.ArrivalSeconds <= 0 {
+			return fmt.Errorf("truck %q needs positive ArrivalSeconds",
+				truck.Name)
+		}
+		if truck.Cost < 1 {
+			return fmt.Errorf("truck %q needs a cost of at least 1", truck.Name)
+		}
+
+		names[truck.Name] = true
+		r.Trucks = append(r.Trucks, truck)
+		r.trucks[truck.ID] = truck
+	}
+
+	for _, truckType := range builtinTrucks {
+		if _, exists := r.trucks[truckType]; !exists {
+			return fmt.Errorf("truck %d is missing", truckType)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) addTiles(tiles []tileFile) error {
+	names := map[string]bool{}
+	for _, tile := range tiles {
+		if tile.ID < 0 {
+			return fmt.Errorf("tile %q has a negative ID", tile.Name)
+		}
+		if _, exists := r.tiles[tile.ID]; exists {
+			return fmt.Errorf("tile ID %d is used twice", tile.ID)
+		}
+		if tile.Name == "" || names[tile.Name] {
+			return fmt.Errorf("tile %d needs a unique name", tile.ID)
+		}
+		if tile.Sprite == "" {
+			return fmt.Errorf("tile %q has no sprite", tile.Name)
+		}
+		if tile.Boost != nil {
+			if !cycleBehaviors[tile.Boost.Behavior] {
+				return fmt.Errorf("tile %q boosts behavior %q, which has no cycle",
+					tile.Name, tile.Boost.Behavior)
+			}
+			if tile.Boost.Speed < 2 {
+				return fmt.Errorf("tile %q needs a boost speed of at least 2",
+					tile.Name)
+			}
+		}
+		if tile.Patches != nil &&
+			(tile.Patches.Count < 1 || tile.Patches.Radius < 0) {
+			return fmt.Errorf("tile %q needs a patch count and radius", tile.Name)
+		}
+
+		names[tile.Name] = true
+		def := &TileDef{
+			ID:      tile.ID,
+			Name:    tile.Name,
+			Sprite:  tile.Sprite,
+			Blocked: tile.Blocked,
+			Boost:   tile.Boost,
+			Patches: tile.Patches,
+		}
+		r.Tiles = append(r.Tiles, def)
+		r.tiles[tile.ID] = def
+	}
+
+	// foundations may become tiles listed after them
+	for _, tile := range tiles {
+		if tile.Foundation == nil {
+			continue
+		}
+		if tile.Blocked {
+			return fmt.Errorf("tile %q is blocked but has a foundation",
+				tile.Name)
+		}
+		if tile.Foundation.Cost < 1 {
+			return fmt.Errorf("tile %q needs a foundation cost of at least 1",
+				tile.Name)
+		}
+		becomes, exists := r.tileNamed(tile.Foundation.Becomes)
+		if !exists {
+			return fmt.Errorf("tile %q has a foundation of unknown tile %q",
+				tile.Name, tile.Foundation.Becomes)
+		}
+		if r.tiles[becomes].Blocked {
+			return fmt.Errorf("tile %q has a foundation of blocked tile %q",
+				tile.Name, tile.Foundation.Becomes)
+		}
+		r.tiles[tile.ID].Foundation = &FoundationDef{
+			Cost:    tile.Foundation.Cost,
+			Becomes: becomes,
+		}
+	}
+
+	for _, tile := range r.Tiles {
+		if tile.Foundation != nil &&
+			r.tiles[tile.Foundation.Becomes].Foundation != nil {
+			return fmt.Errorf("tile %q has a foundation of tile %q, which needs "+
+				"a foundation too", tile.Name, r.tiles[tile.Foundation.Becomes].Name)
+		}
+	}
+
+	for _, tileType := range builtinTiles {
+		if _, exists := r.tiles[tileType]; !exists {
+			return fmt.Errorf("tile %d is missing", tileType)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) itemNamed(name string) (ItemType, bool) {
+	for _, item := range r.Items {
+		if item.Name == name {
+			return item.ID, true
+		}
+	}
+	return 0, false
+}
+
+func (r *Registry) objectNamed(name string) (ObjectType, bool) {
+	for _, object := range r.Objects {
+		if object.Name == name {
+			return object.ID, true
+		}
+	}
+	return 0, false
+}
+
+func (r *Registry) tileNamed(name string) (TileType, bool) {
+	for _, tile := range r.Tiles {
+		if tile.Name == name {
+			return tile.ID, true
+		}
+	}
+	return 0, false
+}
+
+// item returns the definition of an item type, stopping the game if the
+// type is not defined
+func (r *Registry) item(itemType ItemType) *ItemDef {
+	def, exists := r.items[itemType]
+	if !exists {
+		log.Fatalf("Error: unknown item type %d", itemType)
+	}
+	return def
+}
+
+// object returns the definition of an object type, stopping the game if the
+// type is not defined
+func (r *Registry) object(objectType ObjectType) *ObjectDef {
+	def, exists := r.objects[objectType]
+	if !exists {
+		log.Fatalf("Error: unknown object type %d", objectType)
+	}
+	return def
+}
+
+// warehouse returns the definition of a warehouse type, stopping the game
+// if the type is not defined
+func (r *Registry) warehouse(warehouseType WarehouseType) *WarehouseDef {
+	def, exists := r.warehouses[warehouseType]
+	if !exists {
+		log.Fatalf("Error: unknown warehouse type %d", warehouseType)
+	}
+	return def
+}
+
+// truck returns the definition of a truck type, stopping the game if the
+// type is not defined
+func (r *Registry) truck(truckType TruckType) *TruckDef {
+	def, exists := r.trucks[truckType]
+	if !exists {
+		log.Fatalf("Error: unknown truck type %d", truckType)
+	}
+	return def
+}
+
+// tile returns the definition of a tile type, stopping the game if the
+// type is not defined
+func (r *Registry) tile(tileType TileType) *TileDef {
+	def, exists := r.tiles[tileType]
+	if !exists {
+		log.Fatalf("Error: unknown tile type %d", tileType)
+	}
+	return def
+}
+
+// ItemTypes lists every defined ItemType
+func ItemTypes() []ItemType {
+	itemTypes := []ItemType{}
+	for _, item := range registry.Items {
+		itemTypes = append(itemTypes, item.ID)
+	}
+	return itemTypes
+}
+
+// ObjectTypes lists every defined ObjectType
+func ObjectTypes() []ObjectType {
+	objectTypes := []ObjectType{}
+	for _, object := range registry.Objects {
+		objectTypes = append(objectTypes, object.ID)
+	}
+	return objectTypes
+}
+
+// WarehouseTypes lists every defined WarehouseType
+func WarehouseTypes() []WarehouseType {
+	warehouseTypes := []WarehouseType{}
+	for _, warehouse := range registry.Warehouses {
+		warehouseTypes = append(warehouseTypes, warehouse.ID)
+	}
+	return warehouseTypes
+}
+
+// TruckTypes lists every defined TruckType
+func TruckTypes() []TruckType {
+	truckTypes := []TruckType{}
+	for _, truck := range registry.Trucks {
+		truckTypes = append(truckTypes, truck.ID)
+	}
+	return truckTypes
+}
+
+// TileTypes lists every defined TileType
+func TileTypes() []TileType {
+	tileTypes := []TileType{}
+	for _, tile := range registry.Tiles {
+		tileTypes = append(tileTypes, tile.ID)
+	}
+	return tileTypes
+}
+
+// IsItemType returns true if the item type is defined
+func IsItemType(itemType ItemType) bool {
+	_, exists := registry.items[itemType]
+	return exists
+}
+
+// IsObjectType returns true if the object type is defined
+func IsObjectType(objectType ObjectType) bool {
+	_, exists := registry.objects[objectType]
+	return exists
+}
+
+// IsWarehouseType returns true if the warehouse type is defined
+func IsWarehouseType(warehouseType WarehouseType) bool {
+	_, exists := registry.warehouses[warehouseType]
+	return exists
+}
+
+// IsTruckType returns true if the truck type is defined
+func IsTruckType(truckType TruckType) bool {
+	_, exists := registry.trucks[truckType]
+	return exists
+}
+
+// RecipesFor lists the recipes a machine can make
+func RecipesFor(machine ObjectType) []*Recipe {
+	recipes := []*Recipe{}
+	for _, recipe := range registry.Recipes {
+		if recipe.Machine == machine {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes
+}
+
+// RecipeNamed returns the recipe with the given name
+func RecipeNamed(name string) (*Recipe, bool) {
+	for _, recipe := range registry.Recipes {
+		if recipe.Name == name {
+			return recipe, true
+		}
+	}
+	return nil, false
+}
+
+// RecipeFor returns the first recipe a machine can make from a single item
+// of the given type
+func RecipeFor(machine ObjectType, itemType ItemType) (*Recipe, bool) {
+	for _, recipe := range registry.Recipes {
+		if recipe.Machine == machine && len(recipe.Inputs) == 1 &&
+			recipe.Inputs[0].Item == itemType && recipe.Inputs[0].Count == 1 {
+			return recipe, true
+		}
+	}
+	return nil, false
+}
+
+// price returns the cost of the next object when count are owned
+func (c *CostDef) price(count uint64) uint64 {
+	n := float64(count) + 1
+	return uint64(c.Scale * math.Pow(c.Base, n) * math.Pow(n, c.Power))
+}
@@ -0,0 +1,33 @@
+package sim
+
+const defaultRerollBelow = 4 // Rerollers start by rerolling 1 to 3.
+
+// RerollItemOn rerolls the item on a Reroller once if its face is below the
+// reroller's RerollBelow, taking a reroll cycle. The item is then moved on.
+func (w *World) RerollItemOn(object *Object) {
+	isItemOn, item := w.IsItemOn(object)
+	if !isItemOn {
+		return
+	}
+
+	cycleTicks := w.cycleTicks(object)
+
+	// is this a new item?
+	if item.ID != object.RerollItem {
+		object.RerollItem = item.ID
+		object.RerollTicks = 0
+		if item.Face >= object.RerollBelow {
+			object.RerollTicks = cycleTicks
+		}
+	}
+
+	if object.RerollTicks < cycleTicks {
+		object.RerollTicks++
+		if object.RerollTicks == cycleTicks {
+			item.Roll(w.RNG)
+		}
+		return
+	}
+
+	w.MoveItemOn(object)
+}
@@ -0,0 +1,47 @@
+package sim
+
+import "sort"
+
+// RNG is a seedable random number generator. Its whole state is exported so
+// that it is saved with the World and a loaded game continues the same
+// sequence of rolls.
+type RNG struct {
+	Seed  int64  // seed the generator was created with
+	State uint64 // current position in the sequence
+}
+
+// NewRNG constructs an RNG starting from the given seed
+func NewRNG(seed int64) *RNG {
+	return &RNG{
+		Seed:  seed,
+		State: uint64(seed),
+	}
+}
+
+// Uint64 returns the next pseudo-random number using splitmix64
+func (r *RNG) Uint64() uint64 {
+	r.State += 0x9e3779b97f4a7c15
+	z := r.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Intn returns a pseudo-random number in [0, n). Panics if n <= 0.
+func (r *RNG) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	return int(r.Uint64() % uint64(n))
+}
+
+// sortedIDs returns the keys of a map in ascending order, so that updates
+// happen in the same order every run
+func sortedIDs[V any](m map[uint64]V) []uint64 {
+	ids := make([]uint64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,116 @@
+package sim
+
+type DeliveryKind int
+
+const (
+	DeliverAnywhere  DeliveryKind = iota // Unload into the oldest warehouses with room.
+	DeliverWarehouse                     // Unload into the warehouse DeliverTo.
+	DeliverOrder                         // Fill the order DeliverTo, then unload the rest.
+)
+
+// Route is the docks a truck loads at in turn before each delivery, and
+// where it delivers to
+type Route struct {
+	Stops     []uint64 // IDs of the docks visited, in order
+	Delivery  DeliveryKind
+	DeliverTo uint64 // ID of the warehouse or order delivered to
+}
+
+// AddStop adds a dock to the end of a truck's route
+func (t *Truck) AddStop(dock *Dock) {
+	t.Route.Stops = append(t.Route.Stops, dock.ID)
+}
+
+// RemoveStop removes the stop at an index of a truck's route. A truck at or
+// heading to the removed stop carries on to it, then goes on to the stop
+// that followed it. Stop is left one before that stop, which is -1 when the
+// first stop was removed. Returns false if it is the route's only stop
+func (t *Truck) RemoveStop(index int) bool {
+	if len(t.Route.Stops) <= 1 || index < 0 || index >= len(t.Route.Stops) {
+		return false
+	}
+	t.Route.Stops = append(t.Route.Stops[:index], t.Route.Stops[index+1:]...)
+	if index <= t.Stop {
+		t.Stop--
+	}
+	return true
+}
+
+// SetDelivery sets where a truck delivers at the end of its route. The ID is
+// of the warehouse or order delivered to, and ignored by DeliverAnywhere
+func (t *Truck) SetDelivery(kind DeliveryKind, id uint64) {
+	t.Route.Delivery = kind
+	t.Route.DeliverTo = id
+	if kind == DeliverAnywhere {
+		t.Route.DeliverTo = 0
+	}
+}
+
+// parkAt moves a truck off the map and to the back of a dock's queue,
+// leaving the queue of the dock it was at
+func (w *World) parkAt(t *Truck, dock *Dock) {
+	if current, exists := w.Docks[t.DockID]; exists {
+		current.removeFromQueue(t)
+	}
+	dock.Queue = append(dock.Queue, t.ID)
+
+	t.DockID = dock.ID
+	t.CollectorIDs = dock.CollectorIDs
+	t.SpawnX, t.SpawnY = ToReal(dock.SpawnX), ToReal(dock.SpawnY)
+	t.TargetX, t.TargetY = ToReal(dock.TargetX), ToReal(dock.TargetY)
+	t.X, t.Y = t.SpawnX, t.SpawnY
+	t.Width, t.Height = dock.Width, dock.Height
+	t.PercentComplete = 0
+	t.IsExiting = false
+	t.LoadingTicks = 0
+}
+
+// nextStop sends a truck that has left a dock on to the next stop of its
+// route. After the last stop the truck delivers its dice and starts the
+// route again
+func (w *World) nextStop(t *Truck) {
+	if t.Stop+1 < len(t.Route.Stops) {
+		t.Stop++
+	} else {
+		w.Deliver(t)
+		t.Stop = 0
+	}
+
+	dock, exists := w.Docks[t.Route.Stops[t.Stop]]
+	if !exists {
+		dock = w.Docks[t.DockID]
+	}
+	w.parkAt(t, dock)
+}
+
+// Deliver unloads a truck at the end of its route. A truck delivering to an
+// order fills it if it carries the dice, and unloads the rest into the
+// warehouses. Deliveries to a warehouse or order that no longer exists go to
+// any warehouse instead. Dice that don't fit stay in the truck
+func (w *World) Deliver(t *Truck) {
+	route := &t.Route
+	t.Unloaded = 0
+	switch route.Delivery {
+	case DeliverWarehouse:
+		warehouse, exists := w.Warehouses[route.DeliverTo]
+		if exists {
+			t.Unloaded = warehouse.Storage.Unload(t.Storage)
+			t.Retained = t.Storage.Count
+			return
+		}
+		t.SetDelivery(DeliverAnywhere, 0)
+	case DeliverOrder:
+		order, exists := w.Orders[route.DeliverTo]
+		if exists {
+			before := t.Storage.Count
+			w.DeliverOrder(order, t.Storage)
+			t.Unloaded = before - t.Storage.Count
+		} else {
+			t.SetDelivery(DeliverAnywhere, 0)
+		}
+	}
+
+	unloaded, retained := w.UnloadTruck(t.Storage)
+	t.Unloaded += unloaded
+	t.Retained = retained
+}
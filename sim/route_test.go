@@ -0,0 +1,38 @@
+package sim
+
+import "testing"
+
+// TestRemoveCurrentStop checks a truck whose current stop is removed goes on
+// to the stop that followed it
+func TestRemoveCurrentStop(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		stop    int // stop the truck is at
+		removed int // index of the stop removed
+		next    int // index of the original stops visited next
+	}{
+		{"first", 0, 0, 1},
+		{"middle", 1, 1, 2},
+		{"earlier", 2, 0, 1}, // after delivering
+		{"later", 0, 2, 1},
+	} {
+		world := NewWorld(1)
+		world.Currencies[PlainBuck] = 1e6
+		stops := []*Dock{world.SortedDocks()[0]}
+		stops = append(stops, world.BuyDock(PlanDock(North, 10)))
+		stops = append(stops, world.BuyDock(PlanDock(North, 14)))
+		truck := world.SortedTrucks()[0]
+		truck.AddStop(stops[1])
+		truck.AddStop(stops[2])
+		truck.Stop = test.stop
+
+		if !truck.RemoveStop(test.removed) {
+			t.Fatalf("%s: stop not removed", test.name)
+		}
+		world.nextStop(truck)
+		if truck.DockID != stops[test.next].ID {
+			t.Errorf("%s: went to dock %d, want %d", test.name, truck.DockID,
+				stops[test.next].ID)
+		}
+	}
+}
@@ -0,0 +1,719 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SaveVersion is the version of the save format written by MarshalSave.
+// Bump it and add a migration whenever the format changes.
+const SaveVersion = 20
+
+// saveFile is the serialised form of a World. It is kept separate from the
+// World so that runtime fields can change without breaking old saves.
+type saveFile struct {
+	Version    int
+	SavedAt    time.Time
+	Playtime   time.Duration
+	ID         uint64
+	RNG        *RNG
+	Produced   uint64
+	TileStage  [][]int
+	Land       [][]bool
+	Unlocked   []ObjectType
+	Currencies map[CurrencyType]uint64
+	Objects    []objectSave
+	Items      []itemSave
+	Storages   []storageSave
+	Warehouses []warehouseSave
+	Docks      []dockSave
+	Trucks     []truckSave
+	Orders     []orderSave
+	Market     marketSave
+	SellPolicy sellPolicySave
+}
+
+type objectSave struct {
+	ID           uint64
+	Object       ObjectType
+	X, Y         int
+	Facing       CardinalDir
+	IsCollecting bool
+	SplitRatio   [2]int
+	SplitCount   int
+	MergeMode    MergeMode
+	MergeNext    int
+	SortFaces    map[int]bool      `json:",omitempty"`
+	SortItems    map[ItemType]bool `json:",omitempty"`
+	RerollBelow  int
+	RerollItem   uint64
+	RerollTicks  int
+
+	Recipe         string           `json:",omitempty"`
+	AssembleBuffer map[ItemType]int `json:",omitempty"`
+	AssembleTicks  int
+}
+
+type itemSave struct {
+	ID                 uint64
+	Item               ItemType
+	Face               int
+	Currency           CurrencyType
+	X, Y               float64
+	TargetX, TargetY   int
+	CatchupX, CatchupY float64
+}
+
+type storageSave struct {
+	ID        uint64
+	Storage   StorageType
+	Dice      map[ItemType]map[int]uint64
+	Capacity  uint64
+	Count     uint64
+	TypeLimit int
+	TypeCount int
+}
+
+type truckSave struct {
+	ID               uint64
+	Truck            TruckType
+	DockID           uint64
+	StorageID        uint64
+	CollectorIDs     []uint64
+	X, Y             float64
+	SpawnX, SpawnY   float64
+	TargetX, TargetY float64
+	Width, Height    int
+	PercentComplete  float64
+	IsExiting        bool
+	Unloaded         uint64
+	Retained         uint64
+	Route            routeSave
+	Stop             int
+	Dispatch         *dispatchSave `json:",omitempty"`
+	LoadingTicks     uint64
+}
+
+type routeSave struct {
+	Stops     []uint64
+	Delivery  DeliveryKind
+	DeliverTo uint64
+}
+
+type dockSave struct {
+	ID               uint64
+	CollectorIDs     []uint64
+	SpawnX, SpawnY   int
+	TargetX, TargetY int
+	Width, Height    int
+	Queue            []uint64
+	Dispatch         dispatchSave
+}
+
+type dispatchSave struct {
+	Dispatch DispatchMode
+	Seconds  int
+	Mix      map[ItemType]uint64 `json:",omitempty"`
+}
+
+type warehouseSave struct {
+	ID        uint64
+	Warehouse WarehouseType
+	StorageID uint64
+	Upgrades  int
+}
+
+type orderSave struct {
+	ID        uint64
+	Order     OrderKind
+	Item      ItemType
+	Face      int
+	Count     int
+	Reward    uint64
+	Penalty   uint64
+	TicksLeft uint64
+}
+
+type marketSave struct {
+	Demand  map[ItemType]map[int]float64
+	History map[ItemType][]float64
+}
+
+type sellPolicySave struct {
+	HighestFirst bool
+	HoldItems    map[ItemType]bool `json:",omitempty"`
+	Reserve      int
+	PriceFloor   int
+}
+
+// MarshalSave encodes the world in the current save format
+func (w *World) MarshalSave() ([]byte, error) {
+	save := saveFile{
+		Version:    SaveVersion,
+		SavedAt:    w.SavedAt,
+		Playtime:   w.Playtime,
+		ID:         w.ID,
+		RNG:        w.RNG,
+		Produced:   w.Produced,
+		TileStage:  [][]int{},
+		Land:       [][]bool{},
+		Unlocked:   w.Unlocked,
+		Currencies: w.Currencies,
+		Objects:    []objectSave{},
+		Items:      []itemSave{},
+		Storages:   []storageSave{},
+		Warehouses: []warehouseSave{},
+		Docks:      []dockSave{},
+		Trucks:     []truckSave{},
+		Orders:     []orderSave{},
+		Market: marketSave{
+			Demand:  w.Market.Demand,
+			History: w.Market.History,
+		},
+		SellPolicy: sellPolicySave{
+			HighestFirst: w.SellPolicy.HighestFirst,
+			HoldItems:    w.SellPolicy.HoldItems,
+			Reserve:      w.SellPolicy.Reserve,
+			PriceFloor:   w.SellPolicy.PriceFloor,
+		},
+	}
+
+	for _, row := range w.TileStage {
+		save.TileStage = append(save.TileStage, append([]int{}, row[:]...))
+	}
+	for _, row := range w.Land {
+		save.Land = append(save.Land, append([]bool{}, row[:]...))
+	}
+	for _, id := range sortedIDs(w.Objects) {
+		object := w.Objects[id]
+		save.Objects = append(save.Objects, objectSave{
+			ID:           object.ID,
+			Object:       object.Object,
+			X:            object.X,
+			Y:            object.Y,
+			Facing:       object.Facing,
+			IsCollecting: object.IsCollecting,
+			SplitRatio:   object.SplitRatio,
+			SplitCount:   object.SplitCount,
+			MergeMode:    object.MergeMode,
+			MergeNext:    object.MergeNext,
+			SortFaces:    object.SortFaces,
+			SortItems:    object.SortItems,
+			RerollBelow:  object.RerollBelow,
+			RerollItem:   object.RerollItem,
+			RerollTicks:  object.RerollTicks,
+
+			Recipe:         object.Recipe,
+			AssembleBuffer: object.AssembleBuffer,
+			AssembleTicks:  object.AssembleTicks,
+		})
+	}
+	for _, id := range sortedIDs(w.Items) {
+		item := w.Items[id]
+		save.Items = append(save.Items, itemSave{
+			ID:       item.ID,
+			Item:     item.Item,
+			Face:     item.Face,
+			Currency: item.Currency,
+			X:        item.X,
+			Y:        item.Y,
+			TargetX:  item.TargetX,
+			TargetY:  item.TargetY,
+			CatchupX: item.CatchupX,
+			CatchupY: item.CatchupY,
+		})
+	}
+	for _, id := range sortedIDs(w.Storages) {
+		save.Storages = append(save.Storages, saveStorage(w.Storages[id]))
+	}
+	for _, warehouse := range w.SortedWarehouses() {
+		save.Warehouses = append(save.Warehouses, warehouseSave{
+			ID:        warehouse.ID,
+			Warehouse: warehouse.Warehouse,
+			StorageID: warehouse.Storage.ID,
+			Upgrades:  warehouse.Upgrades,
+		})
+	}
+	for _, dock := range w.SortedDocks() {
+		save.Docks = append(save.Docks, dockSave{
+			ID:           dock.ID,
+			CollectorIDs: append([]uint64{}, dock.CollectorIDs...),
+			SpawnX:       dock.SpawnX,
+			SpawnY:       dock.SpawnY,
+			TargetX:      dock.TargetX,
+			TargetY:      dock.TargetY,
+			Width:        dock.Width,
+			Height:       dock.Height,
+			Queue:        append([]uint64{}, dock.Queue...),
+			Dispatch:     newDispatchSave(&dock.Dispatch),
+		})
+	}
+	for _, id := range sortedIDs(w.Trucks) {
+		truck := w.Trucks[id]
+		var dispatch *dispatchSave
+		if truck.Dispatch != nil {
+			rule := newDispatchSave(truck.Dispatch)
+			dispatch = &rule
+		}
+		save.Trucks = append(save.Trucks, truckSave{
+			ID:              truck.ID,
+			Truck:           truck.Truck,
+			DockID:          truck.DockID,
+			StorageID:       truck.Storage.ID,
+			CollectorIDs:    append([]uint64{}, truck.CollectorIDs...),
+			X:               truck.X,
+			Y:               truck.Y,
+			SpawnX:          truck.SpawnX,
+			SpawnY:          truck.SpawnY,
+			TargetX:         truck.TargetX,
+			TargetY:         truck.TargetY,
+			Width:           truck.Width,
+			Height:          truck.Height,
+			PercentComplete: truck.PercentComplete,
+			IsExiting:       truck.IsExiting,
+			Unloaded:        truck.Unloaded,
+			Retained:        truck.Retained,
+			Route: routeSave{
+				Stops:     append([]uint64{}, truck.Route.Stops...),
+				Delivery:  truck.Route.Delivery,
+				DeliverTo: truck.Route.DeliverTo,
+			},
+			Stop:         truck.Stop,
+			Dispatch:     dispatch,
+			LoadingTicks: truck.LoadingTicks,
+		})
+	}
+
+	for _, id := range sortedIDs(w.Orders) {
+		order := w.Orders[id]
+		save.Orders = append(save.Orders, orderSave{
+			ID:        order.ID,
+			Order:     order.Order,
+			Item:      order.Item,
+			Face:      order.Face,
+			Count:     order.Count,
+			Reward:    order.Reward,
+			Penalty:   order.Penalty,
+			TicksLeft: order.TicksLeft,
+		})
+	}
+
+	return json.Marshal(save)
+}
+
+func saveStorage(storage *Storage) storageSave {
+	return storageSave{
+		ID:        storage.ID,
+		Storage:   storage.Storage,
+		Dice:      storage.Dice,
+		Capacity:  storage.Capacity,
+		Count:     storage.Count,
+		TypeLimit: storage.TypeLimit,
+		TypeCount: storage.TypeCount,
+	}
+}
+
+func newDispatchSave(rule *DispatchRule) dispatchSave {
+	return dispatchSave{
+		Dispatch: rule.Dispatch,
+		Seconds:  rule.Seconds,
+		Mix:      rule.Mix,
+	}
+}
+
+// toRule checks a saved dispatch rule of the dock or truck with the given
+// ID, and rebuilds it
+func (save dispatchSave) toRule(owner string, id uint64) (DispatchRule, error) {
+	if save.Dispatch < ManualDispatch || save.Dispatch > DispatchSchedule {
+		return DispatchRule{}, fmt.Errorf(
+			"malformed save: %s %d has unknown dispatch %d", owner, id, save.Dispatch)
+	}
+	if save.Seconds <= 0 {
+		return DispatchRule{}, fmt.Errorf(
+			"malformed save: %s %d has dispatch wait %d", owner, id, save.Seconds)
+	}
+	mix := map[ItemType]uint64{}
+	for itemType, count := range save.Mix {
+		if !IsItemType(itemType) {
+			return DispatchRule{}, fmt.Errorf(
+				"malformed save: %s %d dispatch mix has unknown type %d",
+				owner, id, itemType)
+		}
+		mix[itemType] = count
+	}
+	return DispatchRule{Dispatch: save.Dispatch, Seconds: save.Seconds, Mix: mix}, nil
+}
+
+// UnmarshalSave decodes a save of any known version into a World.
+// Older saves are migrated to the current version first. An error is
+// returned if the save is malformed or newer than SaveVersion.
+func UnmarshalSave(data []byte) (*World, error) {
+	// numbers are kept as json.Number so that IDs and RNG state keep their
+	// precision through the migrations
+	var document map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("malformed save: %w", err)
+	}
+
+	if err := migrate(document); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("malformed save: %w", err)
+	}
+	var save saveFile
+	if err := json.Unmarshal(data, &save); err != nil {
+		return nil, fmt.Errorf("malformed save: %w", err)
+	}
+
+	return save.toWorld()
+}
+
+// toWorld constructs a World from a save, checking every reference
+func (save *saveFile) toWorld() (*World, error) {
+	world := NewEmptyWorld(0)
+	world.ID = save.ID
+	world.SavedAt = save.SavedAt
+	world.Playtime = save.Playtime
+	world.Produced = save.Produced
+	world.Unlocked = save.Unlocked
+
+	if save.RNG == nil {
+		return nil, errors.New("malformed save: missing RNG")
+	}
+	world.RNG = save.RNG
+
+	if save.Currencies != nil {
+		world.Currencies = save.Currencies
+	}
+
+	if len(save.TileStage) != StageSizeY {
+		return nil, fmt.Errorf("malformed save: tile stage has %d rows, want %d",
+			len(save.TileStage), StageSizeY)
+	}
+	for y, row := range save.TileStage {
+		if len(row) != StageSizeX {
+			return nil, fmt.Errorf(
+				"malformed save: tile stage row %d has %d tiles, want %d",
+				y, len(row), StageSizeX)
+		}
+		for x, tile := range row {
+			if !isTileType(tile) {
+				return nil, fmt.Errorf(
+					"malformed save: tile %d,%d has unknown type %d", x, y, tile)
+			}
+		}
+		copy(world.TileStage[y][:], row)
+	}
+
+	if len(save.Land) != LandChunksY {
+		return nil, fmt.Errorf("malformed save: land has %d rows, want %d",
+			len(save.Land), LandChunksY)
+	}
+	for y, row := range save.Land {
+		if len(row) != LandChunksX {
+			return nil, fmt.Errorf(
+				"malformed save: land row %d has %d chunks, want %d",
+				y, len(row), LandChunksX)
+		}
+		copy(world.Land[y][:], row)
+	}
+
+	for _, object := range save.Objects {
+		if _, exists := world.Objects[object.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate object %d", object.ID)
+		}
+		if !IsObjectType(object.Object) {
+			return nil, fmt.Errorf("malformed save: object %d has unknown type %d",
+				object.ID, object.Object)
+		}
+		world.Objects[object.ID] = &Object{
+			ID:           object.ID,
+			Object:       object.Object,
+			X:            object.X,
+			Y:            object.Y,
+			Facing:       object.Facing,
+			IsCollecting: object.IsCollecting,
+			SplitRatio:   object.SplitRatio,
+			SplitCount:   object.SplitCount,
+			MergeMode:    object.MergeMode,
+			MergeNext:    object.MergeNext,
+			SortFaces:    object.SortFaces,
+			SortItems:    object.SortItems,
+			RerollBelow:  object.RerollBelow,
+			RerollItem:   object.RerollItem,
+			RerollTicks:  object.RerollTicks,
+
+			Recipe:         object.Recipe,
+			AssembleBuffer: object.AssembleBuffer,
+			AssembleTicks:  object.AssembleTicks,
+		}
+		for itemType := range object.AssembleBuffer {
+			if !IsItemType(itemType) {
+				return nil, fmt.Errorf(
+					"malformed save: object %d holds unknown item type %d",
+					object.ID, itemType)
+			}
+		}
+		world.ObjectCount[object.Object]++
+	}
+
+	for _, item := range save.Items {
+		if _, exists := world.Items[item.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate item %d", item.ID)
+		}
+		if !IsItemType(item.Item) {
+			return nil, fmt.Errorf("malformed save: item %d has unknown type %d",
+				item.ID, item.Item)
+		}
+		world.Items[item.ID] = &Item{
+			ID:       item.ID,
+			Item:     item.Item,
+			Face:     item.Face,
+			Currency: item.Currency,
+			X:        item.X,
+			Y:        item.Y,
+			TargetX:  item.TargetX,
+			TargetY:  item.TargetY,
+			CatchupX: item.CatchupX,
+			CatchupY: item.CatchupY,
+		}
+	}
+
+	storages := map[uint64]*Storage{}
+	for _, storage := range save.Storages {
+		if _, exists := storages[storage.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate storage %d", storage.ID)
+		}
+		dice := storage.Dice
+		if dice == nil {
+			dice = map[ItemType]map[int]uint64{}
+		}
+		storages[storage.ID] = &Storage{
+			ID:        storage.ID,
+			Storage:   storage.Storage,
+			Dice:      dice,
+			Capacity:  storage.Capacity,
+			Count:     storage.Count,
+			TypeLimit: storage.TypeLimit,
+			TypeCount: storage.TypeCount,
+		}
+	}
+
+	world.Storages = storages
+
+	world.Warehouses = map[uint64]*Warehouse{}
+	for _, warehouse := range save.Warehouses {
+		if _, exists := world.Warehouses[warehouse.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate warehouse %d",
+				warehouse.ID)
+		}
+		if !IsWarehouseType(warehouse.Warehouse) {
+			return nil, fmt.Errorf("malformed save: warehouse %d has unknown type %d",
+				warehouse.ID, warehouse.Warehouse)
+		}
+		storage, exists := world.Storages[warehouse.StorageID]
+		if !exists {
+			return nil, fmt.Errorf(
+				"malformed save: warehouse %d has missing storage %d",
+				warehouse.ID, warehouse.StorageID)
+		}
+		world.Warehouses[warehouse.ID] = &Warehouse{
+			ID:        warehouse.ID,
+			Warehouse: warehouse.Warehouse,
+			Storage:   storage,
+			Upgrades:  warehouse.Upgrades,
+		}
+	}
+	if len(world.Warehouses) == 0 {
+		return nil, errors.New("malformed save: no warehouses")
+	}
+
+	for _, dock := range save.Docks {
+		if _, exists := world.Docks[dock.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate dock %d", dock.ID)
+		}
+		// a dock without collectors never loads the truck in its bay
+		collectorIDs := world.existingCollectors(dock.CollectorIDs)
+		if len(collectorIDs) < 1 {
+			return nil, fmt.Errorf("malformed save: dock %d has no collectors",
+				dock.ID)
+		}
+		dispatch, err := dock.Dispatch.toRule("dock", dock.ID)
+		if err != nil {
+			return nil, err
+		}
+		world.Docks[dock.ID] = &Dock{
+			ID:           dock.ID,
+			CollectorIDs: collectorIDs,
+			SpawnX:       dock.SpawnX,
+			SpawnY:       dock.SpawnY,
+			TargetX:      dock.TargetX,
+			TargetY:      dock.TargetY,
+			Width:        dock.Width,
+			Height:       dock.Height,
+			Queue:        append([]uint64{}, dock.Queue...),
+			Dispatch:     dispatch,
+		}
+	}
+
+	for _, truck := range save.Trucks {
+		if _, exists := world.Trucks[truck.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate truck %d", truck.ID)
+		}
+		if !IsTruckType(truck.Truck) {
+			return nil, fmt.Errorf("malformed save: truck %d has unknown type %d",
+				truck.ID, truck.Truck)
+		}
+		if _, exists := world.Docks[truck.DockID]; !exists {
+			return nil, fmt.Errorf("malformed save: truck %d has missing dock %d",
+				truck.ID, truck.DockID)
+		}
+		storage, exists := world.Storages[truck.StorageID]
+		if !exists {
+			return nil, fmt.Errorf("malformed save: truck %d has missing storage %d",
+				truck.ID, truck.StorageID)
+		}
+		collectorIDs := world.existingCollectors(truck.CollectorIDs)
+		if len(collectorIDs) < 1 {
+			return nil, fmt.Errorf("malformed save: truck %d has no collectors",
+				truck.ID)
+		}
+		route := truck.Route
+		if len(route.Stops) < 1 {
+			return nil, fmt.Errorf("malformed save: truck %d has no route stops",
+				truck.ID)
+		}
+		for _, id := range route.Stops {
+			if _, exists := world.Docks[id]; !exists {
+				return nil, fmt.Errorf("malformed save: truck %d has missing stop %d",
+					truck.ID, id)
+			}
+		}
+		if route.Delivery < DeliverAnywhere || route.Delivery > DeliverOrder {
+			return nil, fmt.Errorf("malformed save: truck %d has unknown delivery %d",
+				truck.ID, route.Delivery)
+		}
+		if truck.Stop < -1 || truck.Stop >= len(route.Stops) {
+			return nil, fmt.Errorf("malformed save: truck %d has invalid stop %d",
+				truck.ID, truck.Stop)
+		}
+		var dispatch *DispatchRule
+		if truck.Dispatch != nil {
+			rule, err := truck.Dispatch.toRule("truck", truck.ID)
+			if err != nil {
+				return nil, err
+			}
+			dispatch = &rule
+		}
+		world.Trucks[truck.ID] = &Truck{
+			ID:              truck.ID,
+			Truck:           truck.Truck,
+			DockID:          truck.DockID,
+			Storage:         storage,
+			CollectorIDs:    collectorIDs,
+			X:               truck.X,
+			Y:               truck.Y,
+			SpawnX:          truck.SpawnX,
+			SpawnY:          truck.SpawnY,
+			TargetX:         truck.TargetX,
+			TargetY:         truck.TargetY,
+			Width:           truck.Width,
+			Height:          truck.Height,
+			PercentComplete: truck.PercentComplete,
+			IsExiting:       truck.IsExiting,
+			Unloaded:        truck.Unloaded,
+			Retained:        truck.Retained,
+			Route: Route{
+				Stops:     route.Stops,
+				Delivery:  route.Delivery,
+				DeliverTo: route.DeliverTo,
+			},
+			Stop:         truck.Stop,
+			Dispatch:     dispatch,
+			LoadingTicks: truck.LoadingTicks,
+		}
+	}
+
+	queued := map[uint64]bool{}
+	for _, dockID := range sortedIDs(world.Docks) {
+		dock := world.Docks[dockID]
+		for _, id := range dock.Queue {
+			truck, exists := world.Trucks[id]
+			if !exists || truck.DockID != dock.ID || queued[id] {
+				return nil, fmt.Errorf("malformed save: dock %d has invalid truck %d",
+					dock.ID, id)
+			}
+			queued[id] = true
+		}
+	}
+	if len(queued) != len(world.Trucks) {
+		return nil, errors.New("malformed save: truck missing from dock queue")
+	}
+
+	for _, order := range save.Orders {
+		if _, exists := world.Orders[order.ID]; exists {
+			return nil, fmt.Errorf("malformed save: duplicate order %d", order.ID)
+		}
+		if !IsItemType(order.Item) {
+			return nil, fmt.Errorf("malformed save: order %d has unknown type %d",
+				order.ID, order.Item)
+		}
+		world.Orders[order.ID] = &Order{
+			ID:        order.ID,
+			Order:     order.Order,
+			Item:      order.Item,
+			Face:      order.Face,
+			Count:     order.Count,
+			Reward:    order.Reward,
+			Penalty:   order.Penalty,
+			TicksLeft: order.TicksLeft,
+		}
+	}
+
+	if save.Market.Demand != nil {
+		world.Market.Demand = save.Market.Demand
+	}
+	if save.Market.History != nil {
+		world.Market.History = save.Market.History
+	}
+	for itemType, faces := range world.Market.Demand {
+		if !IsItemType(itemType) {
+			return nil, fmt.Errorf("malformed save: market has unknown type %d",
+				itemType)
+		}
+		for face, demand := range faces {
+			if demand < minDemand || demand > maxDemand {
+				return nil, fmt.Errorf(
+					"malformed save: market demand %v for %s face %d",
+					demand, itemType, face)
+			}
+		}
+	}
+	for itemType := range world.Market.History {
+		if !IsItemType(itemType) {
+			return nil, fmt.Errorf(
+				"malformed save: market history has unknown type %d", itemType)
+		}
+	}
+
+	world.SellPolicy.HighestFirst = save.SellPolicy.HighestFirst
+	world.SellPolicy.Reserve = save.SellPolicy.Reserve
+	world.SellPolicy.PriceFloor = save.SellPolicy.PriceFloor
+	if save.SellPolicy.HoldItems != nil {
+		world.SellPolicy.HoldItems = save.SellPolicy.HoldItems
+	}
+	if world.SellPolicy.Reserve < 0 || world.SellPolicy.PriceFloor < 0 {
+		return nil, errors.New("malformed save: negative sell policy")
+	}
+
+	world.Reindex()
+	return world, nil
+}
@@ -0,0 +1,52 @@
+package sim
+
+// SortMatches returns true if a Sorter moves the item forward
+func (o *Object) SortMatches(item *Item) bool {
+	return o.SortItems[item.Item] && o.SortFaces[item.Face]
+}
+
+// ToggleSortFace adds or removes a face from the faces a Sorter moves forward
+func (o *Object) ToggleSortFace(face int) {
+	if o.SortFaces == nil {
+		o.SortFaces = map[int]bool{}
+	}
+	if o.SortFaces[face] {
+		delete(o.SortFaces, face)
+	} else {
+		o.SortFaces[face] = true
+	}
+}
+
+// ToggleSortItem adds or removes an item type from the types a Sorter moves
+// forward
+func (o *Object) ToggleSortItem(itemType ItemType) {
+	if o.SortItems == nil {
+		o.SortItems = map[ItemType]bool{}
+	}
+	if o.SortItems[itemType] {
+		delete(o.SortItems, itemType)
+	} else {
+		o.SortItems[itemType] = true
+	}
+}
+
+// SortItemOn moves the item on a Sorter forward if it matches the sorter's
+// faces and item types, or out of its right side if it does not. The item
+// waits on the sorter while its output is blocked.
+func (w *World) SortItemOn(object *Object) {
+	isItemOn, item := w.IsItemOn(object)
+	if !isItemOn {
+		return
+	}
+
+	dir := object.Facing
+	if !object.SortMatches(item) {
+		dir = object.Facing.Right()
+	}
+
+	isItemMoveable, neighbor := w.IsItemMoveableTowards(object, dir)
+	if !isItemMoveable {
+		return
+	}
+	w.MoveItemTo(item, neighbor)
+}
@@ -0,0 +1,54 @@
+package sim
+
+// splitRatios are the ratios a Splitter can be set to, as items sent left
+// and right per cycle
+var splitRatios = [][2]int{{1, 1}, {2, 1}, {1, 2}, {3, 1}, {1, 3}}
+
+// CycleSplitRatio sets a Splitter to the next ratio in splitRatios
+func (o *Object) CycleSplitRatio() {
+	next := 0
+	for index, ratio := range splitRatios {
+		if ratio == o.SplitRatio {
+			next = (index + 1) % len(splitRatios)
+			break
+		}
+	}
+	o.SplitRatio = splitRatios[next]
+	o.SplitCount = 0
+}
+
+// SplitItemOn sends the item on a Splitter to one of its side outputs.
+// The outputs take turns according to SplitRatio. If the output whose turn
+// it is cannot take the item, the other output is tried without using up
+// the turn.
+func (w *World) SplitItemOn(object *Object) {
+	isItemOn, item := w.IsItemOn(object)
+	if !isItemOn {
+		return
+	}
+
+	ratio := object.SplitRatio
+	if ratio[0] <= 0 || ratio[1] <= 0 {
+		ratio = splitRatios[0]
+	}
+	cycle := ratio[0] + ratio[1]
+
+	turn, other := object.Facing.Left(), object.Facing.Right()
+	if object.SplitCount%cycle >= ratio[0] {
+		turn, other = other, turn
+	}
+
+	for _, dir := range []CardinalDir{turn, other} {
+		isItemMoveable, neighbor := w.IsItemMoveableTowards(object, dir)
+		if !isItemMoveable {
+			continue
+		}
+		if !w.MoveItemTo(item, neighbor) {
+			continue
+		}
+		if dir == turn {
+			object.SplitCount = (object.SplitCount + 1) % cycle
+		}
+		return
+	}
+}
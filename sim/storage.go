@@ -1,19 +1,12 @@
-package main
+package sim
 
 type StorageType int
 
 const (
-	Warehouse = iota
+	WarehouseStorage = iota
 	TruckTrailer
 )
 
-const warehouseCapacity uint64 = 1000
-
-const (
-	truckCapacity  uint64 = 10
-	truckTypeLimit int    = 1
-)
-
 type Storage struct {
 	ID        uint64
 	Storage   StorageType
@@ -26,9 +19,9 @@ type Storage struct {
 
 // NewStorage constructs a new Storage.
 // the Dice map is initialised, but containing maps are not.
-func (g *Game) NewStorage(storageType StorageType, capacity uint64, typeLimit int) *Storage {
+func (w *World) NewStorage(storageType StorageType, capacity uint64, typeLimit int) *Storage {
 	return &Storage{
-		ID:        g.NextID(),
+		ID:        w.NextID(),
 		Dice:      map[ItemType]map[int]uint64{},
 		Storage:   storageType,
 		Capacity:  capacity,
@@ -76,8 +69,8 @@ func (s *Storage) StoreDice(item ItemType, face int, count uint64) bool {
 	return false
 }
 
-// RemoveDie removes a die from a storage. Should not be used by trucks,
-// as it will not reduce the TypeCount
+// RemoveDie removes a die from a storage. Removing the last die of a type
+// reduces the TypeCount, so a truck can hold another type in its place
 func (s *Storage) RemoveDie(item ItemType, face int) bool {
 	if s.Count <= 0 {
 		return false
@@ -110,20 +103,3 @@ func (s *Storage) RemoveDie(item ItemType, face int) bool {
 	}
 	return true
 }
-
-// Load adds all the dice in given storage to self.
-// Returns false if the added dice would exceed the capacity
-// Does not currently check type limit
-func (s *Storage) Load(storage *Storage) bool {
-
-	if storage.Count+s.Count > s.Capacity {
-		return false
-	}
-
-	for diceType, dice := range storage.Dice {
-		for face, count := range dice {
-			s.StoreDice(diceType, face, count)
-		}
-	}
-	return true
-}
@@ -0,0 +1,112 @@
+package sim
+
+type TileType int
+
+// Tile types the code refers to. Other types are defined only in the data
+// files
+const (
+	BasicGrass = iota
+	LongGrass
+)
+
+func (t TileType) String() string {
+	return registry.tile(t).Name
+}
+
+// Sprite returns the image file name of the terrain
+func (t TileType) Sprite() string {
+	return registry.tile(t).Sprite
+}
+
+// IsBlocked returns true if nothing can be built on the terrain
+func (t TileType) IsBlocked() bool {
+	return registry.tile(t).Blocked
+}
+
+// Boosts returns the behavior of machines that work faster on the terrain,
+// and how many times faster they work
+func (t TileType) Boosts() (Behavior, int) {
+	boost := registry.tile(t).Boost
+	if boost == nil {
+		return NoBehavior, 1
+	}
+	return boost.Behavior, boost.Speed
+}
+
+// isTileType returns true if the value is a known TileType
+func isTileType(value int) bool {
+	_, exists := registry.tiles[TileType(value)]
+	return exists
+}
+
+// TileAt returns the terrain of a tile coordinate. Tiles off the map are
+// BasicGrass, though nothing can be built there as the land can't be owned
+func (w *World) TileAt(x, y int) TileType {
+	if !IsInStage(x, y) {
+		return BasicGrass
+	}
+	return TileType(w.TileStage[y][x])
+}
+
+// scatterTerrain places patches of each terrain with Patches around the
+// map, in the order the types are listed. The land the factory owns is
+// left as it is
+func (w *World) scatterTerrain() {
+	for _, tile := range registry.Tiles {
+		if tile.Patches == nil {
+			continue
+		}
+		radius := tile.Patches.Radius
+		for i := 0; i < tile.Patches.Count; i++ {
+			centreX, centreY := w.RNG.Intn(StageSizeX), w.RNG.Intn(StageSizeY)
+			for y := centreY - radius; y <= centreY+radius; y++ {
+				for x := centreX - radius; x <= centreX+radius; x++ {
+					dx, dy := x-centreX, y-centreY
+					if dx*dx+dy*dy > radius*radius ||
+						!IsInStage(x, y) || w.IsOwned(x, y) {
+						continue
+					}
+					w.TileStage[y][x] = int(tile.ID)
+				}
+			}
+		}
+	}
+}
+
+// FoundationCost returns the cost of the foundation needed before building
+// on a tile, which is 0 if the terrain needs none
+func (w *World) FoundationCost(x, y int) (CurrencyType, uint64) {
+	foundation := registry.tile(w.TileAt(x, y)).Foundation
+	if foundation == nil {
+		return PlainBuck, 0
+	}
+	return PlainBuck, foundation.Cost
+}
+
+// BuildFoundation will attempt to Pay for the foundation a tile needs and
+// build it. Returns true if the tile needs no foundation or it was built
+func (w *World) BuildFoundation(x, y int) bool {
+	currency, cost := w.FoundationCost(x, y)
+	if cost == 0 {
+		return true
+	}
+	if !w.Pay(currency, cost) {
+		return false
+	}
+	w.TileStage[y][x] = int(registry.tile(w.TileAt(x, y)).Foundation.Becomes)
+	return true
+}
+
+// cycleTicks returns the ticks per build, upgrade, reroll or assembly of an
+// object, which is shorter on terrain that boosts its behavior
+func (w *World) cycleTicks(object *Object) int {
+	ticks := object.Object.cycleTicks()
+	boosts, speed := w.TileAt(object.X, object.Y).Boosts()
+	if boosts != NoBehavior && boosts == object.Object.Behavior() {
+		ticks /= speed
+	}
+	if ticks < 1 {
+		ticks = 1
+	}
+	return ticks
+}
@@ -0,0 +1,40 @@
+package sim
+
+import "testing"
+
+// TestBoostedRerollerIsFaster checks a reroller on terrain that boosts
+// rerollers spends fewer ticks rerolling a die
+func TestBoostedRerollerIsFaster(t *testing.T) {
+	boosted := &TileDef{
+		ID:     TileType(len(registry.Tiles) + 100),
+		Name:   "Boosted",
+		Sprite: "boosted.png",
+		Boost:  &BoostDef{Behavior: RerollerBehavior, Speed: 2},
+	}
+	registry.tiles[boosted.ID] = boosted
+	defer delete(registry.tiles, boosted.ID)
+
+	rerollTicks := func(tile TileType) int {
+		world := NewEmptyWorld(1)
+		world.TileStage[1][1] = int(tile)
+		reroller := world.SpawnObject(Reroller, 1, 1, East)
+		reroller.RerollBelow = PlainD6.Faces() + 1
+		world.SpawnItem(PlainD6, reroller)
+
+		ticks := 0
+		for i := 0; i < TickRate*60; i++ {
+			before := reroller.RerollTicks
+			world.RerollItemOn(reroller)
+			if reroller.RerollTicks != before {
+				ticks++
+			}
+		}
+		return ticks
+	}
+
+	plain, fast := rerollTicks(BasicGrass), rerollTicks(boosted.ID)
+	if fast*boosted.Boost.Speed != plain {
+		t.Fatalf("boosted reroller took %d ticks, want %d", fast,
+			plain/boosted.Boost.Speed)
+	}
+}
@@ -0,0 +1,251 @@
+package sim
+
+import "math"
+
+type TruckType int
+
+// Truck types are defined in data/trucks.json. BasicTruck is the type every
+// factory starts with
+const BasicTruck TruckType = 0
+
+const upkeepSeconds = 60 // Seconds between upkeep charges.
+
+func (t TruckType) String() string {
+	return registry.truck(t).Name
+}
+
+// Sprite returns the image file name of the truck type
+func (t TruckType) Sprite() string {
+	return registry.truck(t).Sprite
+}
+
+// Upkeep returns the PlainBucks a truck of the type costs every upkeepSeconds
+func (t TruckType) Upkeep() uint64 {
+	return registry.truck(t).Upkeep
+}
+
+type TruckStatus int
+
+const (
+	TruckWaiting  TruckStatus = iota // Off the map, waiting for its turn.
+	TruckArriving                    // Driving to its dock's bay.
+	TruckLoading                     // At the bay, being loaded.
+	TruckLeaving                     // Driving to its next stop or to deliver.
+)
+
+func (s TruckStatus) String() string {
+	switch s {
+	case TruckWaiting:
+		return "Waiting"
+	case TruckArriving:
+		return "Arriving"
+	case TruckLoading:
+		return "Loading"
+	case TruckLeaving:
+		return "Leaving"
+	}
+	return "Unknown"
+}
+
+type Truck struct {
+	X, Y             float64
+	SpawnX, SpawnY   float64
+	TargetX, TargetY float64
+	ID               uint64 // unique generated identifier
+	Truck            TruckType
+	DockID           uint64   // ID of the Dock the truck is at or heading to
+	Storage          *Storage // associated Storage
+	CollectorIDs     []uint64 // IDs of the Collector objects loading the truck
+	Width, Height    int      // width along x axis
+
+	PercentComplete float64 // 0 to 1
+	IsExiting       bool
+
+	Unloaded uint64 // dice unloaded into warehouses on the last delivery
+	Retained uint64 // dice kept on the last delivery for lack of room
+
+	Route        Route         // docks loaded at and where dice are delivered
+	Stop         int           // index in Route.Stops of the current dock, -1 if removed from the front
+	Dispatch     *DispatchRule // decides when the truck leaves by itself, nil to use its dock's
+	LoadingTicks uint64        // ticks spent loading since arriving
+}
+
+// GetCollectors resolves a truck's collector IDs through the object registry.
+// Collectors that no longer exist are skipped
+func (w *World) GetCollectors(t *Truck) []*Object {
+	collectors := []*Object{}
+	for _, id := range t.CollectorIDs {
+		collector, exists := w.Objects[id]
+		if exists {
+			collectors = append(collectors, collector)
+		}
+	}
+	return collectors
+}
+
+// IsLoading returns true if the truck's collectors are accepting dice
+func (w *World) IsLoading(t *Truck) bool {
+	collectors := w.GetCollectors(t)
+	return len(collectors) > 0 && collectors[0].IsCollecting &&
+		t.PercentComplete == 1 && !t.IsExiting
+}
+
+// TruckStatus returns what a truck is currently doing
+func (w *World) TruckStatus(t *Truck) TruckStatus {
+	switch {
+	case t.IsExiting:
+		return TruckLeaving
+	case w.IsLoading(t):
+		return TruckLoading
+	case w.Docks[t.DockID].isAtBay(t):
+		return TruckArriving
+	}
+	return TruckWaiting
+}
+
+// SendTruck stops the truck's collectors and sends the truck away
+func (w *World) SendTruck(t *Truck) {
+	for _, collector := range w.GetCollectors(t) {
+		collector.IsCollecting = false
+	}
+	t.IsExiting = true
+}
+
+// Step moves the truck towards its target with decreasing velocity.
+// If truck IsExiting, it moves away from its target with increasing velocity.
+// Returns true on the same tick of arrival
+func (t *Truck) Step() bool {
+	onComplete := false
+	arrivalTime := registry.truck(t.Truck).ArrivalSeconds
+	if t.IsExiting {
+		t.PercentComplete -= TickDelta / arrivalTime
+		if t.PercentComplete < 0 {
+			t.PercentComplete = 0
+			onComplete = true
+		}
+	} else {
+		t.PercentComplete += TickDelta / arrivalTime
+		if t.PercentComplete > 1 {
+			t.PercentComplete = 1
+			onComplete = true
+		}
+	}
+	totalDistance := t.TargetX - t.SpawnX
+	t.X = (-math.Pow(t.PercentComplete-1, 2)+1)*totalDistance + t.SpawnX
+
+	totalDistance = t.TargetY - t.SpawnY
+	t.Y = (-math.Pow(t.PercentComplete-1, 2)+1)*totalDistance + t.SpawnY
+
+	return onComplete
+}
+
+// IsAt returns true if the tile coordinate is covered by the truck
+func (t *Truck) IsAt(x, y int) bool {
+	return x >= ToTile(t.X) &&
+		x < ToTile(t.X)+t.Width &&
+		y >= ToTile(t.Y) &&
+		y < ToTile(t.Y)+t.Height
+}
+
+// UpdateTrucks moves every truck at the front of its dock's queue, and sends
+// loading trucks once their dispatch rule is due. Trucks that finish leaving
+// queue at the next dock of their route, delivering their dice after the
+// last. Upkeep is charged every upkeepSeconds
+func (w *World) UpdateTrucks() {
+	for _, id := range sortedIDs(w.Trucks) {
+		truck := w.Trucks[id]
+		// Is the truck currently being loaded?
+		if w.IsLoading(truck) {
+			truck.LoadingTicks++
+			if w.isDispatchDue(truck) {
+				w.SendTruck(truck)
+			}
+			continue
+		}
+
+		// Is the truck waiting for another to leave the bay?
+		dock := w.Docks[truck.DockID]
+		if !truck.IsExiting && !dock.isAtBay(truck) {
+			continue
+		}
+
+		// Is the truck at it's destination?
+		if truck.PercentComplete == 1 && !truck.IsExiting {
+			continue
+		}
+
+		// Step truck, and on the last frame enable collectors if arriving
+		if truck.Step() {
+			if !truck.IsExiting && truck.PercentComplete == 1 {
+				for _, collector := range w.GetCollectors(truck) {
+					collector.IsCollecting = true
+				}
+			} else {
+				// Let the next truck in, and drive on to the next stop or
+				// deliver the dice
+				w.nextStop(truck)
+			}
+		}
+	}
+
+	if w.Ticks%uint64(TickRate*upkeepSeconds) == 0 {
+		for _, id := range sortedIDs(w.Trucks) {
+			upkeep := w.Trucks[id].Truck.Upkeep()
+			if w.Currencies[PlainBuck] > upkeep {
+				w.Currencies[PlainBuck] -= upkeep
+			} else {
+				w.Currencies[PlainBuck] = 0
+			}
+		}
+	}
+}
+
+// SpawnTruck constructs a new empty truck of the given type with a route to
+// a single dock, and puts it at the back of the dock's queue
+func (w *World) SpawnTruck(truckType TruckType, dock *Dock) *Truck {
+	info := registry.truck(truckType)
+	storage := w.NewStorage(TruckTrailer, info.Capacity, info.TypeLimit)
+	w.Storages[storage.ID] = storage
+
+	truck := &Truck{
+		ID:      w.NextID(),
+		Truck:   truckType,
+		Storage: storage,
+	}
+	w.Trucks[truck.ID] = truck
+	w.AssignTruck(truck, dock)
+
+	return truck
+}
+
+// TruckCost returns the cost of buying another truck of a type.
+// Each truck of a type doubles the cost of the next
+func (w *World) TruckCost(truckType TruckType) (CurrencyType, uint64) {
+	owned := 0
+	for _, truck := range w.Trucks {
+		if truck.Truck == truckType {
+			owned++
+		}
+	}
+	return PlainBuck, registry.truck(truckType).Cost *
+		uint64(math.Pow(2, float64(owned)))
+}
+
+// BuyTruck will attempt to Pay for a truck and assign it to a dock if
+// successful
+func (w *World) BuyTruck(truckType TruckType, dock *Dock) bool {
+	if !w.Pay(w.TruckCost(truckType)) {
+		return false
+	}
+	w.SpawnTruck(truckType, dock)
+	return true
+}
+
+// SortedTrucks returns the trucks, oldest first
+func (w *World) SortedTrucks() []*Truck {
+	trucks := []*Truck{}
+	for _, id := range sortedIDs(w.Trucks) {
+		trucks = append(trucks, w.Trucks[id])
+	}
+	return trucks
+}
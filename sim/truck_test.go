@@ -0,0 +1,35 @@
+package sim
+
+import "testing"
+
+// TestTruckLoadsAfterReload checks a truck keeps loading dice from belts
+// after its world is saved and loaded again
+func TestTruckLoadsAfterReload(t *testing.T) {
+	world := NewWorld(1)
+	truck := world.SortedTrucks()[0]
+	for i := 0; i < TickRate*30 && !world.IsLoading(truck); i++ {
+		world.Tick()
+	}
+	if !world.IsLoading(truck) {
+		t.Fatal("truck never started loading")
+	}
+
+	data, err := world.MarshalSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	world, err = UnmarshalSave(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truck = world.Trucks[truck.ID]
+	before := truck.Storage.Count
+
+	for i := 0; i < TickRate*30 && truck.Storage.Count == before; i++ {
+		world.Tick()
+	}
+	if truck.Storage.Count <= before {
+		t.Fatalf("truck holds %d dice after reloading, want more than %d",
+			truck.Storage.Count, before)
+	}
+}
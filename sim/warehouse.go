@@ -0,0 +1,162 @@
+package sim
+
+import (
+	"math"
+	"sort"
+)
+
+type WarehouseType int
+
+// Warehouse types are defined in data/warehouses.json. BasicWarehouse is
+// the type every factory starts with
+const BasicWarehouse WarehouseType = 0
+
+const (
+	capacityUpgrade     uint64 = 500 // Dice added by each capacity upgrade.
+	capacityUpgradeCost uint64 = 100 // PlainBucks for the first upgrade.
+)
+
+func (t WarehouseType) String() string {
+	return registry.warehouse(t).Name
+}
+
+// SellSeconds returns the seconds between market sales of the type
+func (t WarehouseType) SellSeconds() int {
+	return registry.warehouse(t).SellSeconds
+}
+
+// Warehouse stores dice delivered by trucks until they are sold
+type Warehouse struct {
+	ID        uint64 // same as the ID of its Storage
+	Warehouse WarehouseType
+	Storage   *Storage
+	Upgrades  int // capacity upgrades bought
+}
+
+// SpawnWarehouse constructs a new empty warehouse of the given type
+func (w *World) SpawnWarehouse(warehouseType WarehouseType) *Warehouse {
+	storage := w.NewStorage(
+		WarehouseStorage, registry.warehouse(warehouseType).Capacity, 0)
+	w.Storages[storage.ID] = storage
+
+	warehouse := &Warehouse{
+		ID:        storage.ID,
+		Warehouse: warehouseType,
+		Storage:   storage,
+	}
+	w.Warehouses[warehouse.ID] = warehouse
+	return warehouse
+}
+
+// WarehouseCost returns the cost of buying another warehouse of a type.
+// Each warehouse of a type doubles the cost of the next
+func (w *World) WarehouseCost(warehouseType WarehouseType) (CurrencyType, uint64) {
+	owned := 0
+	for _, warehouse := range w.Warehouses {
+		if warehouse.Warehouse == warehouseType {
+			owned++
+		}
+	}
+	return PlainBuck, registry.warehouse(warehouseType).Cost *
+		uint64(math.Pow(2, float64(owned)))
+}
+
+// BuyWarehouse will attempt to Pay for a warehouse and spawn it if successful
+func (w *World) BuyWarehouse(warehouseType WarehouseType) bool {
+	if !w.Pay(w.WarehouseCost(warehouseType)) {
+		return false
+	}
+	w.SpawnWarehouse(warehouseType)
+	return true
+}
+
+// UpgradeCost returns the cost of the warehouse's next capacity upgrade.
+// Each upgrade doubles the cost of the next
+func (wh *Warehouse) UpgradeCost() (CurrencyType, uint64) {
+	return PlainBuck, capacityUpgradeCost * uint64(math.Pow(2, float64(wh.Upgrades)))
+}
+
+// UpgradeWarehouse will attempt to Pay for a capacity upgrade and apply it
+// if successful
+func (w *World) UpgradeWarehouse(warehouse *Warehouse) bool {
+	if !w.Pay(warehouse.UpgradeCost()) {
+		return false
+	}
+	warehouse.Upgrades++
+	warehouse.Storage.Capacity += capacityUpgrade
+	return true
+}
+
+// SortedWarehouses returns the warehouses, oldest first
+func (w *World) SortedWarehouses() []*Warehouse {
+	warehouses := []*Warehouse{}
+	for _, id := range sortedIDs(w.Warehouses) {
+		warehouses = append(warehouses, w.Warehouses[id])
+	}
+	return warehouses
+}
+
+// Stock returns the number of dice per face per type held across every
+// warehouse
+func (w *World) Stock() map[ItemType]map[int]uint64 {
+	stock := map[ItemType]map[int]uint64{}
+	for _, warehouse := range w.Warehouses {
+		for itemType, faces := range warehouse.Storage.Dice {
+			if _, exists := stock[itemType]; !exists {
+				stock[itemType] = map[int]uint64{}
+			}
+			for face, count := range faces {
+				stock[itemType][face] += count
+			}
+		}
+	}
+	return stock
+}
+
+// RemoveStock removes a die from the oldest warehouse holding one.
+// Returns false if no warehouse holds the die
+func (w *World) RemoveStock(itemType ItemType, face int) bool {
+	for _, warehouse := range w.SortedWarehouses() {
+		if warehouse.Storage.RemoveDie(itemType, face) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnloadTruck moves as many dice from a truck's storage into the warehouses
+// as they have room for, filling the oldest warehouses first. Dice that do
+// not fit stay in the truck.
+// Returns the number of dice unloaded and the number kept in the truck
+func (w *World) UnloadTruck(storage *Storage) (unloaded, retained uint64) {
+	for _, warehouse := range w.SortedWarehouses() {
+		unloaded += warehouse.Storage.Unload(storage)
+	}
+	return unloaded, storage.Count
+}
+
+// Unload moves dice from the given storage into self until self is full.
+// Returns the number of dice moved
+func (s *Storage) Unload(storage *Storage) uint64 {
+	itemTypes := []ItemType{}
+	for itemType := range storage.Dice {
+		itemTypes = append(itemTypes, itemType)
+	}
+	sort.Slice(itemTypes, func(i, j int) bool { return itemTypes[i] < itemTypes[j] })
+
+	var moved uint64
+	for _, itemType := range itemTypes {
+		faces := []int{}
+		for face := range storage.Dice[itemType] {
+			faces = append(faces, face)
+		}
+		sort.Ints(faces)
+		for _, face := range faces {
+			for storage.Dice[itemType][face] > 0 && s.StoreDie(itemType, face) {
+				storage.RemoveDie(itemType, face)
+				moved++
+			}
+		}
+	}
+	return moved
+}
@@ -0,0 +1,146 @@
+// Package sim contains the headless simulation of the dice factory.
+// Nothing in this package depends on a window, GPU or image files, so a World
+// can be advanced and inspected on any machine.
+package sim
+
+import "time"
+
+const TileSize int = 64 // pixels per tile
+
+const (
+	TickRate  int     = 60
+	TickDelta float64 = 1.0 / float64(TickRate)
+)
+
+// The size of the map in tiles, which is larger than the screen. The camera
+// shows part of it at a time
+const (
+	StageSizeX int = 64
+	StageSizeY int = 40
+)
+
+const longGrassChance = 16 // One in this many tiles of a new map is long grass.
+
+const maxUint64 = ^uint64(0)
+
+// ToReal converts a tile coordinate to a real coordinate
+func ToReal(i int) float64 {
+	return float64(i * TileSize)
+}
+
+// ToTile converts a real coordinate to a tile coordinate
+func ToTile(f float64) int {
+	return int(f) / TileSize
+}
+
+// IsInStage returns true if the tile coordinate is on the map
+func IsInStage(x, y int) bool {
+	return x >= 0 && x < StageSizeX && y >= 0 && y < StageSizeY
+}
+
+// World stores all simulation state of a factory
+type World struct {
+	TileStage   [StageSizeY][StageSizeX]int    // Stores Tile instances.
+	Land        [LandChunksY][LandChunksX]bool // Stores which chunks of land are owned.
+	Objects     map[uint64]*Object             // Stores Object instances.
+	Unlocked    []ObjectType                   // Object types available to buy
+	ObjectCount map[ObjectType]uint64          // Tracks the number of Objects
+	Items       map[uint64]*Item               // Stores Item instances.
+	Currencies  map[CurrencyType]uint64        // Stores different currencies
+	Storages    map[uint64]*Storage            // Stores a list of trucks and warehouses
+	Trucks      map[uint64]*Truck
+	Docks       map[uint64]*Dock      // Stores the bays trucks are loaded at
+	Orders      map[uint64]*Order     // Stores the customer orders on the board
+	Market      *Market               // Stores the demand for each die
+	SellPolicy  *SellPolicy           // Decides which dice the warehouse sells
+	Warehouses  map[uint64]*Warehouse // Stores where delivered dice are kept
+	ID          uint64                // Stores id of last item/object made.
+	RNG         *RNG                  // Source of all randomness in the world
+	Produced    uint64                // Stores the number of dice built
+	SavedAt     time.Time             // Stores when the world was last saved
+	Playtime    time.Duration         // Stores time spent playing the world
+
+	Ticks uint64 `json:"-"` // Stores tick count
+
+	objectGrid Grid // Stores Object IDs by tile
+	itemGrid   Grid // Stores Item IDs by target tile
+}
+
+// NextID increments the stored id and returns it
+func (w *World) NextID() uint64 {
+	w.ID++
+	return w.ID
+}
+
+// NewEmptyWorld constructs a World with no tiles, objects or trucks.
+// Worlds made with the same seed and given the same input play out the same.
+func NewEmptyWorld(seed int64) *World {
+	world := World{
+		RNG:         NewRNG(seed),
+		Objects:     map[uint64]*Object{},
+		Unlocked:    []ObjectType{},
+		ObjectCount: map[ObjectType]uint64{},
+		Items:       map[uint64]*Item{},
+		Currencies:  map[CurrencyType]uint64{},
+		Storages:    map[uint64]*Storage{},
+		Trucks:      map[uint64]*Truck{},
+		Docks:       map[uint64]*Dock{},
+		Warehouses:  map[uint64]*Warehouse{},
+		Orders:      map[uint64]*Order{},
+		Market:      NewMarket(),
+		SellPolicy:  NewSellPolicy(),
+		objectGrid:  Grid{},
+		itemGrid:    Grid{},
+	}
+
+	world.SpawnWarehouse(BasicWarehouse)
+	for _, def := range registry.Objects {
+		if def.Unlocked {
+			world.Unlocked = append(world.Unlocked, def.ID)
+		}
+	}
+
+	return &world
+}
+
+// NewWorld constructs the starting factory layout.
+func NewWorld(seed int64) *World {
+	world := NewEmptyWorld(seed)
+
+	// set up tile stage, scattered with long grass
+	for y := 0; y < StageSizeY; y++ {
+		for x := 0; x < StageSizeX; x++ {
+			if world.RNG.Intn(longGrassChance) == 0 {
+				world.TileStage[y][x] = LongGrass
+			}
+		}
+	}
+
+	world.ownStartingPlot()
+	world.scatterTerrain()
+
+	builder := world.SpawnObject(Builder, 6, 4, South)
+	world.SpawnObject(ConveyorBelt, 6, 5, West)
+
+	collector1 := world.SpawnObject(Collector, 5, 5, South)
+	collector2 := world.SpawnObject(Collector, 5, 6, South)
+
+	dock := world.SpawnDock([]uint64{collector1.ID, collector2.ID},
+		-5, 5, 2, 5, 4, 2)
+	world.SpawnTruck(BasicTruck, dock)
+
+	world.SpawnItem(PlainD6, builder)
+
+	return world
+}
+
+// Tick advances the simulation by a single tick.
+func (w *World) Tick() {
+	w.Ticks += 1
+
+	w.UpdateObjects()
+	w.UpdateItems()
+	w.UpdateTrucks()
+	w.UpdateOrders()
+	w.UpdateMarket()
+}
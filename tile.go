@@ -1,19 +1,16 @@
 package main
 
 import (
+	"image/color"
 	_ "image/png"
 	"log"
 
+	"github.com/Rolls71/dice-factory/sim"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
-type TileType int
-
-const (
-	BasicGrass = iota
-	LongGrass
-)
+var foundationNeeded color.RGBA = color.RGBA{0x8a, 0x8a, 0x3a, 0x88}
 
 type Tile struct {
 	Name  string
@@ -22,7 +19,7 @@ type Tile struct {
 
 // NewTile adds a new type of Tiles to the game's tileSet.
 func (g *Game) NewTile(
-	tile TileType,
+	tile sim.TileType,
 	imageName string,
 ) {
 	path := "images/" + imageName
@@ -33,18 +30,44 @@ func (g *Game) NewTile(
 	g.tileImages[tile] = img
 }
 
-// DrawTiles will draw every Tile in the game's list of objects.
-// Tiles are drawn on their stored grid coordinate.
+// DrawTiles will draw every Tile in the world's tile stage that the camera
+// can see. Tiles are drawn on their stored grid coordinate.
 func (g *Game) DrawTiles(screen *ebiten.Image) {
-	for y := 0; y < stageSizeY; y++ {
-		for x := 0; x < stageSizeX; x++ {
-			img := g.tileImages[TileType(g.TileStage[y][x])]
+	left, top := g.camera.ToWorld(0, 0)
+	right, bottom := g.camera.ToWorld(screenWidth, screenHeight)
+	for y := int(top) / tileSize; y <= int(bottom)/tileSize; y++ {
+		for x := int(left) / tileSize; x <= int(right)/tileSize; x++ {
+			if !sim.IsInStage(x, y) {
+				continue
+			}
+			img := g.tileImages[g.world.TileAt(x, y)]
 			options := &ebiten.DrawImageOptions{}
 			options.GeoM.Scale(float64(tileSize)/float64(img.Bounds().Dx()),
 				float64(tileSize)/float64(img.Bounds().Dy()))
 			options.GeoM.Translate(float64(x*tileSize), float64(y*tileSize))
+			options.GeoM.Concat(g.camera.GeoM())
 			screen.DrawImage(img, options)
 		}
 	}
 
 }
+
+// DrawPlacement highlights the tile under the cursor while an object is
+// dragged: green if it can be placed there, yellow if the terrain needs a
+// foundation first and red otherwise
+func (g *Game) DrawPlacement(screen *ebiten.Image) {
+	if !g.isDragging {
+		return
+	}
+	tileX, tileY := g.GetCursorCoordinates()
+	fill := dockInvalid
+	if g.world.CanPlace(tileX, tileY) {
+		fill = dockValid
+		if _, cost := g.world.FoundationCost(tileX, tileY); cost > 0 {
+			fill = foundationNeeded
+		}
+	}
+	x, y := g.camera.ToScreen(sim.ToReal(tileX), sim.ToReal(tileY))
+	size := float64(tileSize) * g.camera.Zoom
+	ebitenutil.DrawRect(screen, x, y, size, size, fill)
+}
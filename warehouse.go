@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Rolls71/dice-factory/sim"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const warehouseButtonWidth = 120
+
+// onWarehouse opens or closes the warehouse panel if the right key has been
+// pressed. The key is passed as a parameter
+func (g *Game) onWarehouse(key ebiten.Key) {
+	if !inpututil.IsKeyJustPressed(key) || g.isDragging {
+		return
+	}
+	isOpen := g.isWarehouseOpen
+	g.ClosePanel()
+	g.isWarehouseOpen = !isOpen
+}
+
+// BuildWarehousePanel returns a panel of the warehouses' sell policy, buttons
+// to upgrade or buy warehouses, and buttons to sell each face of the chosen
+// type of die now
+func (g *Game) BuildWarehousePanel() *Panel {
+	world := g.world
+	policy := world.SellPolicy
+
+	policies := []PanelButton{
+		{
+			Label:   "Highest",
+			IsOn:    policy.HighestFirst,
+			OnClick: func() { policy.HighestFirst = !policy.HighestFirst },
+		},
+		{
+			Label:   fmt.Sprintf("Keep %d", policy.Reserve),
+			IsOn:    policy.Reserve > 0,
+			OnClick: policy.CycleReserve,
+		},
+		{
+			Label:   fmt.Sprintf("Floor %d%%", policy.PriceFloor),
+			IsOn:    policy.PriceFloor > 0,
+			OnClick: policy.CyclePriceFloor,
+		},
+	}
+
+	holds := []PanelButton{{Label: "Hold:"}}
+	sells := []PanelButton{{Label: "Sell:"}}
+	for _, itemType := range world.ProducibleTypes() {
+		itemType := itemType
+		holds = append(holds, PanelButton{
+			Label:   itemType.String(),
+			IsOn:    policy.HoldItems[itemType],
+			OnClick: func() { policy.ToggleHold(itemType) },
+		})
+		sells = append(sells, PanelButton{
+			Label:   itemType.String(),
+			IsOn:    g.warehouseItem == itemType,
+			OnClick: func() { g.warehouseItem = itemType },
+		})
+	}
+
+	upgrades := []PanelButton{}
+	for index, warehouse := range world.SortedWarehouses() {
+		warehouse := warehouse
+		_, cost := warehouse.UpgradeCost()
+		upgrades = append(upgrades, PanelButton{
+			Label:   fmt.Sprintf("Upgrade #%d: %d", index+1, cost),
+			OnClick: func() { world.UpgradeWarehouse(warehouse) },
+		})
+	}
+
+	buys := []PanelButton{}
+	for _, warehouseType := range sim.WarehouseTypes() {
+		warehouseType := warehouseType
+		_, cost := world.WarehouseCost(warehouseType)
+		buys = append(buys, PanelButton{
+			Label:   fmt.Sprintf("%s %d", warehouseType, cost),
+			OnClick: func() { world.BuyWarehouse(warehouseType) },
+		})
+	}
+
+	rows := [][]PanelButton{policies, upgrades, buys, holds, sells}
+	itemType := g.warehouseItem
+	if sim.IsItemType(itemType) {
+		stock := world.Stock()
+		for face := 1; face <= itemType.Faces(); face++ {
+			face := face
+			if (face-1)%panelRowButtons == 0 {
+				rows = append(rows, []PanelButton{})
+			}
+			held := stock[itemType][face]
+			rows[len(rows)-1] = append(rows[len(rows)-1], PanelButton{
+				Label:   fmt.Sprintf("%d x%d", face, held),
+				IsOn:    held > 0,
+				OnClick: func() { world.SellNow(itemType, face) },
+			})
+		}
+	}
+
+	return &Panel{
+		Title:       "Warehouses: sell policy, upgrades, held dice, and faces to sell now",
+		Rows:        rows,
+		ButtonWidth: warehouseButtonWidth,
+	}
+}